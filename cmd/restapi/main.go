@@ -1,16 +1,25 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
-	
+	"os/signal"
+	"syscall"
+	"time"
+
 	"restapi/internal/app"
 	"restapi/internal/config"
+	"restapi/internal/lib/jwtutil"
 	"restapi/internal/lib/logger"
 	"restapi/internal/lib/sl"
-	"restapi/internal/models/postgresql"
+	"restapi/internal/lib/tracing"
 )
 
+// tracingFlushTimeout bounds the export of the pending spans at exit
+const tracingFlushTimeout = 5 * time.Second
+
 
 func main() {
 	cfg := config.MustLoadConfig()
@@ -19,14 +28,69 @@ func main() {
 	log.Info("starting RESTful API")
 	log.Debug("debug message", slog.String("env", cfg.Env))
 
-	db := postgresql.NewPostgreSQL(cfg)
-	defer db.Close()
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(cfg, log, os.Args[2:]); err != nil {
+			log.Error("failed to migrate", sl.Err(err))
+			os.Exit(1)
+		}
 
-	err := app.App(db, log, cfg)
-	if err != nil {
+		os.Exit(0)
+	}
+
+	// run returns before os.Exit, so its deferred cleanup is not skipped
+	if err := run(cfg, log); err != nil {
 		log.Error("failed to run server", sl.Err(err))
 		os.Exit(1)
 	}
 
 	os.Exit(0)
-}
\ No newline at end of file
+}
+
+// run serves until SIGINT or SIGTERM, then shuts the server down and closes the storage last
+func run(cfg *config.Config, log *slog.Logger) error {
+	tokens, err := jwtutil.NewManagerFromConfig(cfg.JWT)
+	if err != nil {
+		return fmt.Errorf("failed to set up jwt keys: %w", err)
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer func() {
+		// runs after the storage is closed, so the spans of the last requests are flushed
+		ctx, cancel := context.WithTimeout(context.Background(), tracingFlushTimeout)
+		defer cancel()
+
+		if err := shutdownTracing(ctx); err != nil {
+			log.Error("failed to flush traces", sl.Err(err))
+		}
+	}()
+
+	if err := prepareSchema(cfg, log); err != nil {
+		return fmt.Errorf("failed to prepare database schema: %w", err)
+	}
+
+	db, err := app.NewStorage(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to set up storage: %w", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Error("failed to close storage", sl.Err(err))
+		}
+		log.Info("storage closed")
+	}()
+
+	log.Info("storage ready", slog.String("driver", cfg.Database.Driver))
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// a second signal kills the process instead of waiting for the drain
+		<-ctx.Done()
+		stop()
+	}()
+
+	return app.App(ctx, db, tokens, log, cfg)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"restapi/internal/app"
+	"restapi/internal/config"
+	"restapi/internal/lib/migrator"
+)
+
+const migrateUsage = "usage: restapi migrate up|down|status|redo"
+
+// migrate runs the migrate subcommand
+func migrate(cfg *config.Config, log *slog.Logger, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+
+	m, closeDB, err := app.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if m == nil {
+		log.Info("storage driver has no migrations", slog.String("driver", cfg.Database.Driver))
+		return nil
+	}
+
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			log.Info("applied migration", slog.Int64("version", migration.Version), slog.String("name", migration.Name))
+		}
+		if err == nil && len(applied) == 0 {
+			log.Info("no pending migration")
+		}
+		return err
+	case "down":
+		migration, err := m.Down(ctx)
+		if err != nil {
+			return err
+		}
+		log.Info("rolled back migration", slog.Int64("version", migration.Version), slog.String("name", migration.Name))
+	case "redo":
+		migration, err := m.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		log.Info("redid migration", slog.Int64("version", migration.Version), slog.String("name", migration.Name))
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if status.AppliedAt != nil {
+				appliedAt = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d  %-32s  %s\n", status.Version, status.Name, appliedAt)
+		}
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	return nil
+}
+
+// prepareSchema applies pending migrations when migrate_on_boot is set, otherwise only checks them.
+// Either way an applied migration whose file changed stops the start.
+func prepareSchema(cfg *config.Config, log *slog.Logger) error {
+	m, closeDB, err := app.NewMigrator(cfg)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	if m == nil {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	if cfg.MigrateOnBoot {
+		applied, err := m.Up(ctx)
+		for _, migration := range applied {
+			log.Info("applied migration", slog.Int64("version", migration.Version), slog.String("name", migration.Name))
+		}
+		return err
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	if pending := migrator.Pending(statuses); len(pending) > 0 {
+		log.Warn("database has pending migrations, run `restapi migrate up`", slog.Int("pending", len(pending)))
+	}
+
+	return nil
+}
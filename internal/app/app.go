@@ -1,70 +1,254 @@
 package app
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"restapi/internal/config"
 	"restapi/internal/http-server/handlers"
 	"restapi/internal/http-server/middleware"
 	"restapi/internal/http-server/middleware/logger"
+	"restapi/internal/lib/health"
+	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/lifecycle"
+	"restapi/internal/lib/metrics"
+	"restapi/internal/lib/migrator"
+	"restapi/internal/lib/ratelimit"
+	"restapi/internal/lib/revocation"
+	"restapi/internal/lib/servicesig"
+	"restapi/internal/lib/tracing"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/user"
 	"restapi/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
-func App (storage storage.Storage, log *slog.Logger, cfg *config.Config) error {
-	server := setupServer(*cfg, log, storage)
+// App serves until ctx is done, then shuts down: the server is marked not ready,
+// keeps serving for the shutdown delay so load balancers stop routing to it,
+// drains in-flight requests and stops the background workers.
+// The storage is left open for the caller to close last.
+func App(ctx context.Context, db storage.Storage, tokens *jwtutil.Manager, log *slog.Logger, cfg *config.Config) error {
+	nonces, closeNonces, err := NewNonceStore(ctx, cfg.InternalAuth)
+	if err != nil {
+		return fmt.Errorf("failed to set up internal auth: %w", err)
+	}
+	defer closeNonces()
+
+	verifier, err := servicesig.NewVerifier(cfg.InternalAuth, nonces)
+	if err != nil {
+		return fmt.Errorf("failed to set up internal auth: %w", err)
+	}
+
+	// the migrator keeps its own connection for the readiness check and is closed before the storage
+	m, closeMigrator, err := NewMigrator(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to set up migration check: %w", err)
+	}
+	defer closeMigrator()
+
+	// checks and pool metrics read the storage directly, everything else goes through the observers
+	checks := healthChecks(cfg, db, m)
+	appMetrics := metrics.New(db)
+	db = storage.WithObservers(db, appMetrics, tracing.NewStorageTracer(cfg.Database.Driver))
+
+	limiter, closeLimiter, err := NewRateLimitStore(ctx, cfg.RateLimit)
+	if err != nil {
+		return fmt.Errorf("failed to set up rate limiting: %w", err)
+	}
+	defer closeLimiter()
+
+	lc := lifecycle.New()
+	server := setupServer(*cfg, log, db, tokens, verifier, lc, checks, appMetrics, limiter)
+
+	if interval := cfg.Idempotency.PurgeInterval; interval > 0 {
+		lc.Go(func(ctx context.Context) {
+			purgeExpiredIdempotencyKeys(ctx, db, log, interval)
+		})
+	}
+
+	if interval := cfg.JWT.RevocationPurgeInterval; interval > 0 {
+		lc.Go(func(ctx context.Context) {
+			purgeExpiredRevokedTokens(ctx, db, log, interval)
+		})
+	}
+
+	if interval := cfg.Tasks.TrashPurgeInterval; interval > 0 {
+		lc.Go(func(ctx context.Context) {
+			purgeTrashedTasks(ctx, db, log, interval, cfg.Tasks.TrashRetention)
+		})
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	lc.SetReady(true)
 	log.Info("Serving on address", slog.String("address", cfg.Address))
-	return server.ListenAndServe()
+
+	select {
+	case err := <-serveErr:
+		lc.SetReady(false)
+		stopWorkers(lc, log, cfg.DrainTimeout)
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("shutting down", slog.String("delay", cfg.ShutdownDelay.String()), slog.String("drain_timeout", cfg.DrainTimeout.String()))
+	lc.SetReady(false)
+	time.Sleep(cfg.ShutdownDelay)
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.DrainTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(drainCtx); err != nil {
+		log.Error("failed to drain requests", sl.Err(err))
+		server.Close()
+	}
+	if err := <-serveErr; !errors.Is(err, http.ErrServerClosed) {
+		log.Error("server stopped with error", sl.Err(err))
+	}
+
+	stopWorkers(lc, log, cfg.DrainTimeout)
+	log.Info("server stopped")
+
+	return nil
 }
 
-func setupRouter (db storage.Storage, log *slog.Logger, cfg config.ServiceAddresses) *gin.Engine {
+func stopWorkers(lc *lifecycle.Lifecycle, log *slog.Logger, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := lc.Stop(ctx); err != nil {
+		log.Error("failed to stop background workers", sl.Err(err))
+	}
+}
+
+// healthChecks lists the dependencies /readyz and /health check
+func healthChecks(cfg *config.Config, db storage.Storage, m *migrator.Migrator) []health.Check {
+	checks := []health.Check{health.Database(db)}
+
+	if cfg.Database.Driver != config.DriverMemory {
+		checks = append(checks, health.Pool(db, cfg.Health.MaxPoolWait, cfg.Health.PoolWindow))
+	}
+	if m != nil {
+		checks = append(checks, health.Migrations(m))
+	}
+
+	return checks
+}
+
+func setupRouter (db storage.Storage, tokens *jwtutil.Manager, verifier *servicesig.Verifier, lc *lifecycle.Lifecycle, checks []health.Check, appMetrics *metrics.Metrics, limiter ratelimit.Store, log *slog.Logger, cfg *config.Config) *gin.Engine {
 	router := gin.Default()
 
+	// client IPs, and with them the rate limit buckets, come from X-Forwarded-For only behind a trusted proxy
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Error("failed to set trusted proxies, client IPs are taken from the connection", sl.Err(err))
+	}
+
 	middleware.LoadRouterWithMiddleware(router, 
-		middleware.CorsWithConfig(cfg), 
+		middleware.RequestIDMiddleware(),
+		middleware.Metrics(appMetrics),
+		middleware.Tracing(),
+		middleware.CorsWithConfig(cfg.ServiceAddresses), 
 		logger.URLFormat(),
 		logger.New(log),
-		middleware.RequestIDMiddleware(),
+		middleware.RequestTimeout(cfg.RequestTimeout),
 	)
 	
-	appHandlers := handlers.NewHandlers(db, log)
+	checker := revocation.NewChecker(db, cfg.JWT.RevocationCacheTTL)
+	appHandlers := handlers.NewHandlers(db, log, cfg, tokens, checker, lc, checks, appMetrics)
+
+	rateLimit := func(group string) gin.HandlerFunc {
+		return middleware.RateLimit(log, limiter, group, rateLimitOf(cfg.RateLimit, group))
+	}
+	idempotent := middleware.Idempotency(log, db, cfg.Idempotency.TTL)
 
 	router.GET("/", func(ctx *gin.Context) {
 		ctx.String(http.StatusOK, "Hello World!")
 	})
 
+	router.GET("/healthz", appHandlers.Health.Liveness)
+	router.GET("/readyz", appHandlers.Health.Readiness)
+	router.GET("/health", middleware.ServiceAuth(log, verifier), appHandlers.Health.Health)
+	router.GET("/metrics", gin.WrapH(appMetrics.Handler()))
+
+	router.GET("/.well-known/jwks.json", appHandlers.Auth.JWKS)
+
+	authRoute := router.Group("/auth")
+	authRoute.Use(rateLimit(rateLimitAuth))
+	{
+		authRoute.POST("/login", appHandlers.Auth.Login)
+		authRoute.POST("/refresh", appHandlers.Auth.Refresh)
+	}
+
 	privateRoute := router.Group("/user")
-	privateRoute.Use(middleware.AllowInternalRequests(log))
+	privateRoute.Use(middleware.ServiceAuth(log, verifier), rateLimit(rateLimitSignup))
 	{
-		privateRoute.POST("", appHandlers.User.SaveUser)
+		privateRoute.POST("", idempotent, appHandlers.User.SaveUser)
 	}
 
 	publicProtectedRoute := router.Group("")
-	publicProtectedRoute.Use(middleware.JWNAuthMiddleware())
+	publicProtectedRoute.Use(middleware.JWNAuthMiddleware(tokens, checker))
 	{
+		authProtectedRouter := publicProtectedRoute.Group("/auth")
+		authProtectedRouter.Use(rateLimit(rateLimitAuth))
+		{
+			authProtectedRouter.POST("/logout", appHandlers.Auth.Logout)
+			authProtectedRouter.POST("/logout-all", appHandlers.Auth.LogoutAll)
+		}
+
 		userRouter := publicProtectedRoute.Group("/user")
+		userRouter.Use(rateLimit(rateLimitUser))
 		{
 			userRouter.GET("", appHandlers.User.GetUser)
+			userRouter.PATCH("", appHandlers.User.PatchUser)
 			userRouter.PUT("/password", appHandlers.User.UpdateUserPassword)
 			userRouter.DELETE("", appHandlers.User.DeleteUser)
 		}
 
 		taskRouter := publicProtectedRoute.Group("/tasks")
+		taskRouter.Use(rateLimit(rateLimitTasks))
 		{
-			taskRouter.POST("", appHandlers.Task.SaveTask)
+			taskRouter.POST("", idempotent, appHandlers.Task.SaveTask)
 			taskRouter.GET("", appHandlers.Task.GetTasksByUserID)
+			taskRouter.GET("/search", appHandlers.Task.SearchTasks)
+			taskRouter.GET("/trash", appHandlers.Task.GetTrashedTasks)
+			taskRouter.DELETE("/trash/:taskId", appHandlers.Task.PurgeTask)
 			taskRouter.GET("/:taskId", appHandlers.Task.GetTaskByTaskID)
 			taskRouter.PUT("/:taskId", appHandlers.Task.UpdateTask)
+			taskRouter.PATCH("/:taskId", appHandlers.Task.PatchTask)
 			taskRouter.DELETE("/:taskId", appHandlers.Task.DeleteTask)
+			taskRouter.POST("/:taskId/restore", appHandlers.Task.RestoreTask)
+			taskRouter.PUT("/:taskId/status", appHandlers.Task.UpdateTaskStatus)
+			taskRouter.POST("/:taskId/toggle", appHandlers.Task.ToggleTaskCompletion)
+			taskRouter.PUT("/:taskId/due", appHandlers.Task.UpdateTaskDueAt)
+			taskRouter.PUT("/:taskId/priority", appHandlers.Task.UpdateTaskPriority)
+		}
+
+		adminRouter := publicProtectedRoute.Group("/admin")
+		adminRouter.Use(middleware.RequireRole(user.RoleAdmin), rateLimit(rateLimitAdmin))
+		{
+			adminRouter.GET("/users", appHandlers.Admin.GetUsers)
+			adminRouter.POST("/users/:userId/disable", appHandlers.Admin.DisableUser)
+			adminRouter.POST("/users/:userId/enable", appHandlers.Admin.EnableUser)
+			adminRouter.PUT("/users/:userId/role", appHandlers.Admin.SetUserRole)
+			adminRouter.GET("/users/:userId/tasks", appHandlers.Admin.GetUserTasks)
+			adminRouter.DELETE("/users/:userId/tasks/:taskId", appHandlers.Admin.DeleteUserTask)
+			adminRouter.GET("/roles", appHandlers.Admin.GetRoles)
+			adminRouter.POST("/roles", appHandlers.Admin.SaveRole)
 		}
 	}
 
 	return router
 }
 
-func setupServer(cfg config.Config, log *slog.Logger, db storage.Storage) *http.Server {
-	router := setupRouter(db, log, cfg.ServiceAddresses)
+func setupServer(cfg config.Config, log *slog.Logger, db storage.Storage, tokens *jwtutil.Manager, verifier *servicesig.Verifier, lc *lifecycle.Lifecycle, checks []health.Check, appMetrics *metrics.Metrics, limiter ratelimit.Store) *http.Server {
+	router := setupRouter(db, tokens, verifier, lc, checks, appMetrics, limiter, log, &cfg)
 	log.Info("Router was set up")
 
 	server := &http.Server{
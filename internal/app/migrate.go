@@ -0,0 +1,43 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"restapi/internal/config"
+	"restapi/internal/lib/migrator"
+	"restapi/internal/models/postgresql"
+	"restapi/internal/models/sqlite"
+)
+
+// NewMigrator creates the migrator of the database driver and a function closing its connection.
+// The memory driver has no schema, so it returns a nil migrator.
+func NewMigrator(cfg *config.Config) (*migrator.Migrator, func() error, error) {
+	var db *sql.DB
+	var err error
+	dir := cfg.MigrationPath
+
+	switch cfg.Database.Driver {
+	case config.DriverPostgres:
+		db, err = postgresql.Open(cfg.Database)
+	case config.DriverSQLite:
+		db, err = sqlite.Open(cfg.Database.Path)
+		dir = filepath.Join(cfg.MigrationPath, "sqlite")
+	case config.DriverMemory:
+		return nil, func() error { return nil }, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage driver %q", cfg.Database.Driver)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	m, err := migrator.New(db, cfg.Database.Driver, dir)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return m, db.Close, nil
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"restapi/internal/config"
+	"restapi/internal/lib/ratelimit"
+)
+
+// route groups with their own rate limit
+const (
+	rateLimitAuth   = "auth"
+	rateLimitSignup = "signup"
+	rateLimitUser   = "user"
+	rateLimitTasks  = "tasks"
+	rateLimitAdmin  = "admin"
+)
+
+// defaultRateLimits apply to the route groups missing from rate_limit.groups
+var defaultRateLimits = map[string]config.RateLimit{
+	rateLimitAuth:   {Requests: 10, Per: time.Minute},
+	rateLimitSignup: {Requests: 30, Per: time.Minute},
+	rateLimitUser:   {Requests: 60, Per: time.Minute},
+	rateLimitTasks:  {Requests: 300, Per: time.Minute, Burst: 60},
+	rateLimitAdmin:  {Requests: 120, Per: time.Minute},
+}
+
+// rateLimitOf returns the configured limit of a route group, or its default
+func rateLimitOf(cfg config.RateLimitConfig, group string) config.RateLimit {
+	if limit, ok := cfg.Groups[group]; ok {
+		return limit
+	}
+
+	return defaultRateLimits[group]
+}
+
+// NewRateLimitStore creates the configured token bucket store and a function closing its connection
+func NewRateLimitStore(ctx context.Context, cfg config.RateLimitConfig) (ratelimit.Store, func() error, error) {
+	switch cfg.Store {
+	case config.RateLimitStoreMemory, "":
+		return ratelimit.NewMemoryStore(), func() error { return nil }, nil
+	case config.RateLimitStoreRedis:
+		client, err := connectRedis(ctx, cfg.RedisAddr, cfg.RedisPasswordEnv)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return ratelimit.NewRedisStore(client), client.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown rate limit store %q", cfg.Store)
+	}
+}
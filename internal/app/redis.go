@@ -0,0 +1,25 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// connectRedis opens a client to the Redis server at addr and checks that it answers;
+// the password is read from the environment variable passwordEnv
+func connectRedis(ctx context.Context, addr, passwordEnv string) (*redis.Client, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     addr,
+		Password: os.Getenv(passwordEnv),
+	})
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis at %s: %w", addr, err)
+	}
+
+	return client, nil
+}
@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"restapi/internal/config"
+	"restapi/internal/lib/servicesig"
+)
+
+// NewNonceStore creates the configured store of HMAC request nonces and a function closing its connection
+func NewNonceStore(ctx context.Context, cfg config.InternalAuth) (servicesig.NonceStore, func() error, error) {
+	switch cfg.NonceStore {
+	case config.NonceStoreMemory, "":
+		// a nonce only has to be remembered while its timestamp is acceptable
+		return servicesig.NewNonceCache(2 * cfg.MaxClockSkew), func() error { return nil }, nil
+	case config.NonceStoreRedis:
+		client, err := connectRedis(ctx, cfg.RedisAddr, cfg.RedisPasswordEnv)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return servicesig.NewRedisNonceStore(client), client.Close, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown nonce store %q", cfg.NonceStore)
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"fmt"
+
+	"restapi/internal/config"
+	"restapi/internal/models/memory"
+	"restapi/internal/models/postgresql"
+	"restapi/internal/models/sqlite"
+	"restapi/internal/storage"
+)
+
+// NewStorage creates the storage selected by the database driver.
+// Every call to it is bounded by database.query_timeout.
+func NewStorage(cfg *config.Config) (storage.Storage, error) {
+	var db storage.Storage
+
+	switch cfg.Database.Driver {
+	case config.DriverPostgres:
+		db = postgresql.NewPostgreSQL(cfg)
+	case config.DriverMemory:
+		db = memory.NewMemory()
+	case config.DriverSQLite:
+		db = sqlite.NewSQLite(cfg)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Database.Driver)
+	}
+
+	return storage.WithQueryTimeout(db, cfg.Database.QueryTimeout), nil
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"restapi/internal/lib/sl"
+	"restapi/internal/storage"
+)
+
+// purgeExpiredIdempotencyKeys deletes the expired idempotency keys every interval until ctx is done.
+// Expired keys are ignored anyway, this only keeps the table small.
+func purgeExpiredIdempotencyKeys(ctx context.Context, db storage.Storage, log *slog.Logger, interval time.Duration) {
+	log = log.With(slog.String("worker", "purgeExpiredIdempotencyKeys"))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := db.DeleteExpiredIdempotencyKeys(ctx)
+		if err != nil {
+			log.Error("failed to purge expired idempotency keys", sl.Err(err))
+			continue
+		}
+		if deleted > 0 {
+			log.Info("purged expired idempotency keys", slog.Int64("deleted", deleted))
+		}
+	}
+}
+
+// purgeExpiredRevokedTokens deletes the revoked access tokens that have expired every interval until ctx is done.
+// An expired token is rejected anyway, so its denylist entry is no longer needed.
+func purgeExpiredRevokedTokens(ctx context.Context, db storage.Storage, log *slog.Logger, interval time.Duration) {
+	log = log.With(slog.String("worker", "purgeExpiredRevokedTokens"))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := db.DeleteExpiredRevokedTokens(ctx)
+		if err != nil {
+			log.Error("failed to purge expired revoked tokens", sl.Err(err))
+			continue
+		}
+		if deleted > 0 {
+			log.Info("purged expired revoked tokens", slog.Int64("deleted", deleted))
+		}
+	}
+}
+
+// purgeTrashedTasks deletes the tasks that have been in the trash longer than retention
+// every interval until ctx is done
+func purgeTrashedTasks(ctx context.Context, db storage.Storage, log *slog.Logger, interval, retention time.Duration) {
+	log = log.With(slog.String("worker", "purgeTrashedTasks"))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		purged, err := db.PurgeTrashedTasks(ctx, time.Now().Add(-retention))
+		if err != nil {
+			log.Error("failed to purge trashed tasks", sl.Err(err))
+			continue
+		}
+		if purged > 0 {
+			log.Info("purged trashed tasks", slog.Int64("purged", purged))
+		}
+	}
+}
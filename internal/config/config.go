@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fmt"
 	"log"
+	"net"
 	"os"
 	"time"
 
@@ -12,30 +14,150 @@ const (
 	configPathEnvKey = "CONFIG_PATH"
 )
 
+// storage drivers
+const (
+	DriverPostgres = "postgres"
+	DriverMemory   = "memory"
+	DriverSQLite   = "sqlite"
+)
+
 type Config struct {
-	Env 			string 			`yaml:"env" 			env-default:"development"`
-	MigrationPath 	string 			`yaml:"migrationPath" 	env-required:"true"`
-	Database 		StorageConfig 	`yaml:"database" 										env-required:"true"`
-	HTTPServer 						`yaml:"http_server"										env-required:"true"`
-	ServiceAddresses				`yaml:"cors"			env-default:"localhost:8080"`
+	Env              string        `yaml:"env" env-default:"development"`
+	MigrationPath    string        `yaml:"migrationPath" env-required:"true"`
+	MigrateOnBoot    bool          `yaml:"migrate_on_boot" env-default:"false"` // apply pending migrations at startup instead of only checking them
+	Database         StorageConfig `yaml:"database" env-required:"true"`
+	HTTPServer       `yaml:"http_server" env-required:"true"`
+	ServiceAddresses `yaml:"cors"`
+	JWT              JWTConfig         `yaml:"jwt"`
+	InternalAuth     InternalAuth      `yaml:"internal_auth"`
+	Health           HealthConfig      `yaml:"health"`
+	Tracing          TracingConfig     `yaml:"tracing"`
+	RateLimit        RateLimitConfig   `yaml:"rate_limit"`
+	Idempotency      IdempotencyConfig `yaml:"idempotency"`
+	Tasks            TasksConfig       `yaml:"tasks"`
 }
 
 type StorageConfig struct {
-	Host 			string 			`yaml:"host" 			env-default:"localhost"	`
-	Port 			string 			`yaml:"port" 			env-default:"5432" 		`
-	DatabaseName 	string 			`yaml:"databaseName" 	env-default:"postgres" 	`
-	User 			string 			`yaml:"user" 			env-default:"postgres" 	`
-	Password 		string 			`yaml:"password" 		env-default:"1488" 		`
+	Driver       string `yaml:"driver" env-default:"postgres"`
+	Host         string `yaml:"host" env-default:"localhost"`
+	Port         string `yaml:"port" env-default:"5432"`
+	DatabaseName string `yaml:"databaseName" env-default:"postgres"`
+	User         string `yaml:"user" env-default:"postgres"`
+	Password     string `yaml:"password" env-default:"1488"`
+	Path         string `yaml:"path" env-default:"./data/restapi.db"` // database file of the sqlite driver
+
+	QueryTimeout time.Duration `yaml:"query_timeout" env-default:"3s"`  // upper bound of every storage call
+	MaxOpenConns int           `yaml:"max_open_conns" env-default:"25"` // postgres connection pool size; sqlite always uses one
 }
 
 type HTTPServer struct {
-	Address 		string 			`yaml:"address"			env-default:"localhost:8080`
-	Timeout 		time.Duration	`yaml:"timeout" 										env-required:"true"`
-	IddleTimeout	time.Duration	`yaml:"iddle_timeout" 									env-required:"true"`
+	Address      string        `yaml:"address" env-default:"localhost:8080"`
+	Timeout      time.Duration `yaml:"timeout" env-required:"true"`
+	IddleTimeout time.Duration `yaml:"iddle_timeout" env-required:"true"`
+
+	RequestTimeout time.Duration `yaml:"request_timeout" env-default:"4s"` // deadline of the request context, below timeout so the 504 can still be written
+
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env-default:"5s"` // time between turning not ready and draining, for load balancers to notice
+	DrainTimeout  time.Duration `yaml:"drain_timeout" env-default:"20s"` // upper bound for in-flight requests and background workers to finish
+
+	TrustedProxies []string `yaml:"trusted_proxies"` // IPs or CIDRs whose X-Forwarded-For is believed, none by default
+}
+
+// HealthConfig tunes the checks behind /readyz and /health
+type HealthConfig struct {
+	Timeout     time.Duration `yaml:"timeout" env-default:"2s"`          // per check
+	MaxPoolWait time.Duration `yaml:"max_pool_wait" env-default:"100ms"` // average wait for a pooled connection above which the pool counts as saturated
+	PoolWindow  time.Duration `yaml:"pool_window" env-default:"1m"`      // how far back the pool waits are averaged
+}
+
+// TracingConfig selects where the OpenTelemetry spans are exported
+type TracingConfig struct {
+	Exporter    string  `yaml:"exporter" env-default:"none"`           // otlp, stdout or none
+	Endpoint    string  `yaml:"endpoint" env-default:"localhost:4318"` // OTLP/HTTP collector address
+	Insecure    bool    `yaml:"insecure" env-default:"true"`           // plain HTTP to the collector
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`          // share of new traces recorded; sampled callers are always followed
+	ServiceName string  `yaml:"service_name" env-default:"restapi"`
+}
+
+// IdempotencyConfig sets how long the responses to requests with an Idempotency-Key are replayed
+type IdempotencyConfig struct {
+	TTL           time.Duration `yaml:"ttl" env-default:"24h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env-default:"1h"` // how often expired keys are deleted
+}
+
+// TasksConfig tunes the task endpoints
+type TasksConfig struct {
+	RequireIfMatch     bool          `yaml:"require_if_match" env-default:"false"`  // refuse updates and deletes without If-Match with 428
+	TrashRetention     time.Duration `yaml:"trash_retention" env-default:"720h"`    // how long deleted tasks can be restored
+	TrashPurgeInterval time.Duration `yaml:"trash_purge_interval" env-default:"1h"` // how often tasks past the retention are purged
+}
+
+// rate limit stores
+const (
+	RateLimitStoreMemory = "memory"
+	RateLimitStoreRedis  = "redis"
+)
+
+// RateLimitConfig sets where the token buckets are kept and the limit of each route group
+type RateLimitConfig struct {
+	Store            string               `yaml:"store" env-default:"memory"` // memory, per replica, or redis, shared
+	RedisAddr        string               `yaml:"redis_addr" env-default:"localhost:6379"`
+	RedisPasswordEnv string               `yaml:"redis_password_env"` // environment variable holding the redis password
+	Groups           map[string]RateLimit `yaml:"groups"`             // route groups left out keep their default limit
+}
+
+// RateLimit is a token bucket of Burst requests, refilled with Requests every Per.
+// Zero Requests lifts the limit.
+type RateLimit struct {
+	Requests int           `yaml:"requests"`
+	Per      time.Duration `yaml:"per"`
+	Burst    int           `yaml:"burst"` // Requests if zero
 }
 
 type ServiceAddresses struct {
-	Addresses 		[]string		`yaml:"addresses"`
+	Addresses []string `yaml:"addresses"`
+}
+
+type JWTConfig struct {
+	AccessTokenTTL          time.Duration `yaml:"access_token_ttl" env-default:"15m"`
+	RefreshTokenTTL         time.Duration `yaml:"refresh_token_ttl" env-default:"720h"`
+	RevocationCacheTTL      time.Duration `yaml:"revocation_cache_ttl" env-default:"30s"`
+	RevocationPurgeInterval time.Duration `yaml:"revocation_purge_interval" env-default:"1h"` // how often expired revoked tokens are deleted
+	Keys                    []JWTKey      `yaml:"keys"`
+}
+
+type JWTKey struct {
+	KID            string `yaml:"kid" env-required:"true"`
+	Algorithm      string `yaml:"algorithm" env-default:"EdDSA"`
+	PrivateKeyPath string `yaml:"private_key_path"`
+	PublicKeyPath  string `yaml:"public_key_path"`
+	Status         string `yaml:"status" env-default:"verify"`
+}
+
+// nonce stores
+const (
+	NonceStoreMemory = "memory"
+	NonceStoreRedis  = "redis"
+)
+
+// InternalAuth lists the credentials internal services use to call private routes
+type InternalAuth struct {
+	APIKeys          []APIKey      `yaml:"api_keys"`
+	HMACKeys         []HMACKey     `yaml:"hmac_keys"`
+	MaxClockSkew     time.Duration `yaml:"max_clock_skew" env-default:"5m"`
+	NonceStore       string        `yaml:"nonce_store" env-default:"memory"` // memory, per replica, or redis, shared
+	RedisAddr        string        `yaml:"redis_addr" env-default:"localhost:6379"`
+	RedisPasswordEnv string        `yaml:"redis_password_env"` // environment variable holding the redis password
+}
+
+type APIKey struct {
+	Name    string `yaml:"name"`
+	KeyHash string `yaml:"key_hash"` // hex encoded sha256 of the key
+}
+
+type HMACKey struct {
+	KeyID     string `yaml:"key_id"`
+	SecretEnv string `yaml:"secret_env"` // environment variable holding the shared secret
 }
 
 func MustLoadConfig () *Config {
@@ -53,5 +175,24 @@ func MustLoadConfig () *Config {
 		log.Fatalf("failed to read config file: %s", err.Error())
 	}
 
+	if err := cfg.validate(); err != nil {
+		log.Fatalf("invalid config: %s", err.Error())
+	}
+
 	return &cfg
-}
\ No newline at end of file
+}
+
+// validate checks the settings that can't be expressed with struct tags
+func (cfg *Config) validate() error {
+	if cfg.RequestTimeout > 0 && cfg.RequestTimeout >= cfg.Timeout {
+		return fmt.Errorf("http_server.request_timeout (%s) must be shorter than http_server.timeout (%s)", cfg.RequestTimeout, cfg.Timeout)
+	}
+
+	for _, proxy := range cfg.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("http_server.trusted_proxies: %q is neither an IP nor a CIDR", proxy)
+		}
+	}
+
+	return nil
+}
@@ -13,8 +13,37 @@ var (
 	ErrInvalidCredentials  							= errors.New("invalid credentials")
 	ErrInvalidPassword								= errors.New("invalid password")
 	ErrValidation									= errors.New("error while validation")
+	ErrRefreshTokenNotFound							= errors.New("refresh token not found")
+	ErrRefreshTokenExpired							= errors.New("refresh token expired")
+	ErrRefreshTokenReused							= errors.New("refresh token reused")
+	ErrInvalidRefreshToken							= errors.New("invalid refresh token")
+	ErrTokenRevoked									= errors.New("token has been revoked")
+	ErrUserDisabled									= errors.New("user is disabled")
+	ErrRoleNotFound									= errors.New("role not found")
+	ErrDuplicateRole								= errors.New("duplicate role")
+	ErrForbidden									= errors.New("insufficient role")
+	ErrServiceUnauthorized							= errors.New("service authentication failed")
+	ErrRateLimited									= errors.New("too many requests")
+	ErrIdempotencyKeyExists							= errors.New("idempotency key already used")
+	ErrIdempotencyKeyNotFound						= errors.New("idempotency key not found")
+	ErrIdempotencyKeyMismatch						= errors.New("idempotency key was used for a different request")
+	ErrIdempotencyKeyInProgress						= errors.New("a request with this idempotency key is in progress")
+	ErrAuthorizationMissing							= errors.New("authorization header missing")
+	ErrInvalidAuthorization							= errors.New("invalid authorization header format")
+	ErrInvalidToken									= errors.New("invalid token")
+	ErrInvalidTokenClaims							= errors.New("invalid token claims")
+	ErrInvalidIdempotencyKey						= errors.New("idempotency key must be 1 to 255 characters")
+	ErrInvalidPathParameter							= errors.New("invalid ID in path")
+	ErrMalformedBody								= errors.New("malformed request body")
+	ErrBodyTooLarge									= errors.New("request body too large")
+	ErrDisableSelf									= errors.New("cannot disable own account")
+	ErrShuttingDown									= errors.New("shutting down")
+	ErrNotReady										= errors.New("not ready")
+	ErrVersionMismatch								= errors.New("task was modified since the given version")
+	ErrPreconditionRequired							= errors.New("If-Match header required")
+	ErrInvalidPatch									= errors.New("invalid patch document")
+	ErrPatchTestFailed								= errors.New("patch test operation failed")
+	ErrUnsupportedMediaType							= errors.New("unsupported media type")
 	ErrForeignKeyConstraintViolation pq.ErrorCode 	= "23503"
 	ErrBindRequest    								= "failed to bind request"
-	ErrAuthorizationMissing							= "authorization header missing"
-	ErrInvalidAuthorization							= "invalid authorization header format"
 )
\ No newline at end of file
@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"context"
+	"log/slog"
+
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/audit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordAudit persists an admin action. The audit log line is written first,
+// so the action is still traceable if persisting the entry fails.
+func (a AdminHandler) recordAudit(c *gin.Context, entry audit.Entry) {
+	logger := helper.LoadLogger(a.auditLog, c, entry.Action)
+
+	attrs := []any{slog.Int64("actorId", entry.ActorID)}
+	if entry.TargetUserID != nil {
+		attrs = append(attrs, slog.Int64("targetUserId", *entry.TargetUserID))
+	}
+	if entry.TargetTaskID != nil {
+		attrs = append(attrs, slog.Int64("targetTaskId", *entry.TargetTaskID))
+	}
+	if entry.Details != "" {
+		attrs = append(attrs, slog.String("details", entry.Details))
+	}
+
+	logger.Info("admin action", attrs...)
+
+	if _, err := a.db.SaveAuditLog(context.WithoutCancel(c.Request.Context()), &entry); err != nil {
+		logger.Error("failed to save audit log", sl.Err(err))
+	}
+}
@@ -0,0 +1,80 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/audit"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DisableUser implements AdminHandlers.
+func (a AdminHandler) DisableUser(c *gin.Context) {
+	const op = "handlers.admin.AdminHandler.DisableUser"
+	a.setUserDisabled(c, op, true)
+}
+
+// EnableUser implements AdminHandlers.
+func (a AdminHandler) EnableUser(c *gin.Context) {
+	const op = "handlers.admin.AdminHandler.EnableUser"
+	a.setUserDisabled(c, op, false)
+}
+
+func (a AdminHandler) setUserDisabled(c *gin.Context, op string, disabled bool) {
+	// load logger with necessary data
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID from token and param
+	actorID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	userID := helper.GetIDFromParams(c, helper.UserIDKey)
+	if actorID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	if disabled && actorID == userID {
+		logger.Warn("admin tried to disable their own account")
+		response.Error(c, errorset.ErrDisableSelf)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userID), slog.Bool("disabled", disabled))
+
+	// action with db
+	if err := a.db.SetUserDisabled(c.Request.Context(), userID, disabled); err != nil {
+		handleAdminUserError(c, logger, err)
+		return
+	}
+
+	action := audit.ActionEnableUser
+	if disabled {
+		a.checker.RevokeUser(userID, time.Now())
+		action = audit.ActionDisableUser
+	}
+
+	a.recordAudit(c, audit.Entry{ActorID: actorID, Action: action, TargetUserID: &userID})
+
+	response.Ok(c, http.StatusOK, nil)
+}
+
+func handleAdminUserError(c *gin.Context, log *slog.Logger, err error) {
+	if errors.Is(err, errorset.ErrUserNotFound) || errors.Is(err, errorset.ErrRoleNotFound) {
+		log.Error(err.Error(), sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	log.Error("failed to update user", sl.Err(err))
+	response.Error(c, err)
+}
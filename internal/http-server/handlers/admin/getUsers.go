@@ -0,0 +1,43 @@
+package admin
+
+import (
+	"net/http"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/audit"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUsers implements AdminHandlers.
+func (a AdminHandler) GetUsers(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.admin.AdminHandler.GetUsers"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID from token
+	actorID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if actorID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// action with db
+	users, err := a.db.GetUsers(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to get users", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	a.recordAudit(c, audit.Entry{ActorID: actorID, Action: audit.ActionListUsers})
+
+	var data data.Data = data.NewData()
+	data[helper.UsersKey] = users
+
+	response.Ok(c, http.StatusOK, data)
+}
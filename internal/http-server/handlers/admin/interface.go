@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"log/slog"
+	"restapi/internal/lib/revocation"
+	"restapi/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandlers interface {
+	GetUsers(c *gin.Context)
+	DisableUser(c *gin.Context)
+	EnableUser(c *gin.Context)
+	SetUserRole(c *gin.Context)
+	GetUserTasks(c *gin.Context)
+	DeleteUserTask(c *gin.Context)
+	GetRoles(c *gin.Context)
+	SaveRole(c *gin.Context)
+}
+
+type AdminHandler struct {
+	log      *slog.Logger
+	auditLog *slog.Logger
+	db       storage.Storage
+	checker  *revocation.Checker
+}
+
+func NewAdminHandler(log *slog.Logger, db storage.Storage, checker *revocation.Checker) AdminHandlers {
+	return AdminHandler{
+		log:      log,
+		auditLog: log.With(slog.String("component", "audit")),
+		db:       db,
+		checker:  checker,
+	}
+}
+
+type roleRequest struct {
+	Role string `json:"role" binding:"required,alphanum,max=32"`
+}
@@ -0,0 +1,57 @@
+package admin
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/audit"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetUserRole implements AdminHandlers.
+func (a AdminHandler) SetUserRole(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.admin.AdminHandler.SetUserRole"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID from token and param
+	actorID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	userID := helper.GetIDFromParams(c, helper.UserIDKey)
+	if actorID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	// bind request
+	var req roleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userID), slog.String(helper.RoleKey, req.Role))
+
+	// action with db
+	if err := a.db.SetUserRole(c.Request.Context(), userID, req.Role); err != nil {
+		handleAdminUserError(c, logger, err)
+		return
+	}
+
+	// tokens carrying the old role were revoked
+	a.checker.RevokeUser(userID, time.Now())
+
+	a.recordAudit(c, audit.Entry{ActorID: actorID, Action: audit.ActionSetUserRole, TargetUserID: &userID, Details: "role=" + req.Role})
+
+	response.Ok(c, http.StatusOK, nil)
+}
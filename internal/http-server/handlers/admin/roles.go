@@ -0,0 +1,86 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/audit"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRoles implements AdminHandlers.
+func (a AdminHandler) GetRoles(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.admin.AdminHandler.GetRoles"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID from token
+	actorID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if actorID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// action with db
+	roles, err := a.db.GetRoles(c.Request.Context())
+	if err != nil {
+		logger.Error("failed to get roles", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	a.recordAudit(c, audit.Entry{ActorID: actorID, Action: audit.ActionListRoles})
+
+	var data data.Data = data.NewData()
+	data[helper.RolesKey] = roles
+
+	response.Ok(c, http.StatusOK, data)
+}
+
+// SaveRole implements AdminHandlers.
+func (a AdminHandler) SaveRole(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.admin.AdminHandler.SaveRole"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID from token
+	actorID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if actorID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// bind request
+	var req roleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.String(helper.RoleKey, req.Role))
+
+	// action with db
+	if err := a.db.SaveRole(c.Request.Context(), req.Role); err != nil {
+		if errors.Is(err, errorset.ErrDuplicateRole) {
+			logger.Warn(err.Error())
+			response.Error(c, err)
+			return
+		}
+
+		logger.Error("failed to save role", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	a.recordAudit(c, audit.Entry{ActorID: actorID, Action: audit.ActionSaveRole, Details: "role=" + req.Role})
+
+	response.Ok(c, http.StatusCreated, nil)
+}
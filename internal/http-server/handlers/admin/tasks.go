@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/audit"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+	"restapi/internal/models/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetUserTasks implements AdminHandlers.
+func (a AdminHandler) GetUserTasks(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.admin.AdminHandler.GetUserTasks"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID from token and param
+	actorID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	userID := helper.GetIDFromParams(c, helper.UserIDKey)
+	if actorID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	// parse query
+	query, err := task.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		logger.Warn("invalid task query", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userID))
+
+	// action with db
+	page, err := a.db.GetTasksByUserID(c.Request.Context(), userID, query)
+	if err != nil {
+		handleAdminTaskError(c, logger, err)
+		return
+	}
+
+	a.recordAudit(c, audit.Entry{ActorID: actorID, Action: audit.ActionListUserTasks, TargetUserID: &userID})
+
+	var data data.Data = data.NewData()
+	data[helper.TasksKey] = page.Tasks
+	if page.NextCursor != "" {
+		data[helper.NextCursorKey] = page.NextCursor
+	}
+
+	response.Ok(c, http.StatusOK, data)
+}
+
+// DeleteUserTask implements AdminHandlers.
+func (a AdminHandler) DeleteUserTask(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.admin.AdminHandler.DeleteUserTask"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID from token and params
+	actorID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	userID := helper.GetIDFromParams(c, helper.UserIDKey)
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if actorID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+	if userID == -1 || taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userID), slog.Int64(helper.TaskIDKey, taskID))
+
+	// action with db
+	if err := a.db.DeleteTask(c.Request.Context(), userID, taskID, task.AnyVersion); err != nil {
+		handleAdminTaskError(c, logger, err)
+		return
+	}
+
+	a.recordAudit(c, audit.Entry{ActorID: actorID, Action: audit.ActionDeleteUserTask, TargetUserID: &userID, TargetTaskID: &taskID})
+
+	response.Ok(c, http.StatusOK, nil)
+}
+
+func handleAdminTaskError(c *gin.Context, log *slog.Logger, err error) {
+	if errors.Is(err, errorset.ErrUserNotFound) || errors.Is(err, errorset.ErrTaskNotFound) {
+		log.Error(err.Error(), sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	log.Error("failed to manage user tasks", sl.Err(err))
+	response.Error(c, err)
+}
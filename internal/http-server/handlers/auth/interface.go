@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"log/slog"
+	"restapi/internal/config"
+	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/revocation"
+	"restapi/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandlers interface {
+	Login(c *gin.Context)
+	Refresh(c *gin.Context)
+	Logout(c *gin.Context)
+	LogoutAll(c *gin.Context)
+	JWKS(c *gin.Context)
+}
+
+type AuthHandler struct {
+	log     *slog.Logger
+	db      storage.Storage
+	cfg     config.JWTConfig
+	tokens  *jwtutil.Manager
+	checker *revocation.Checker
+}
+
+func NewAuthHandler(log *slog.Logger, db storage.Storage, cfg config.JWTConfig, tokens *jwtutil.Manager, checker *revocation.Checker) AuthHandlers {
+	return AuthHandler{
+		log:     log,
+		db:      db,
+		cfg:     cfg,
+		tokens:  tokens,
+		checker: checker,
+	}
+}
+
+type loginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refreshToken" binding:"required"`
+}
+
+type logoutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
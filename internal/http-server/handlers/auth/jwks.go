@@ -0,0 +1,14 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// JWKS implements AuthHandlers.
+// The key set is served as a bare JWKS document, as expected by JWT libraries.
+func (a AuthHandler) JWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, a.tokens.JWKS())
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/hashtool"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/refreshtoken"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/response"
+	"restapi/internal/models/token"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// dummyHash is compared against when the username is unknown, so that
+// missing users take as long to reject as wrong passwords
+const dummyHash = "$2a$10$M5Fku5Vb7XlCn8W7OHcFr.fWOI.zuUWQso5Y2bocoWreGMXWPdixS"
+
+// Login implements AuthHandlers.
+func (a AuthHandler) Login(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.auth.AuthHandler.Login"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// bind request
+	var req loginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.String(helper.UsernameKey, req.Username))
+
+	// action with db
+	userObject, err := a.db.GetUserByUsername(c.Request.Context(), req.Username)
+	if err != nil {
+		if errors.Is(err, errorset.ErrUserNotFound) {
+			_ = hashtool.BcryptCompare(dummyHash, req.Password)
+		}
+		handleLoginError(c, logger, err)
+		return
+	}
+
+	if err := hashtool.BcryptCompare(userObject.Password, req.Password); err != nil {
+		handleLoginError(c, logger, errorset.ErrInvalidCredentials)
+		return
+	}
+
+	if userObject.Disabled() {
+		handleLoginError(c, logger, errorset.ErrUserDisabled)
+		return
+	}
+
+	accessToken, err := a.tokens.GenerateJWT(userObject.UserID, userObject.Role, a.cfg.AccessTokenTTL)
+	if err != nil {
+		logger.Error("failed to generate access token", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	rawRefreshToken, refreshTokenHash, err := refreshtoken.Generate()
+	if err != nil {
+		logger.Error("failed to generate refresh token", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	// every login starts a new refresh token family
+	_, err = a.db.SaveRefreshToken(c.Request.Context(), &token.RefreshToken{
+		TokenHash: refreshTokenHash,
+		UserID:    userObject.UserID,
+		FamilyID:  uuid.New().String(),
+		ExpiresAt: time.Now().Add(a.cfg.RefreshTokenTTL),
+	})
+	if err != nil {
+		handleLoginError(c, logger, err)
+		return
+	}
+
+	logger.Info("user logged in successfully", slog.Int64(helper.UserIDKey, userObject.UserID))
+	response.Ok(c, http.StatusOK, a.tokensData(accessToken, rawRefreshToken))
+}
+
+func handleLoginError(c *gin.Context, log *slog.Logger, err error) {
+	if errors.Is(err, errorset.ErrUserNotFound) || errors.Is(err, errorset.ErrInvalidCredentials) {
+		log.Warn(errorset.ErrInvalidCredentials.Error(), sl.Err(err))
+		response.Error(c, errorset.ErrInvalidCredentials)
+		return
+	}
+
+	if errors.Is(err, errorset.ErrUserDisabled) {
+		log.Warn(err.Error(), sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	log.Error("failed to log in user", sl.Err(err))
+	response.Error(c, err)
+}
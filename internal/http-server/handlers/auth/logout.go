@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/refreshtoken"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Logout implements AuthHandlers.
+// It revokes the access token used for the request and, if given, the refresh token family it belongs to.
+func (a AuthHandler) Logout(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.auth.AuthHandler.Logout"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch claims of the current token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	claims, _ := helper.FetchClaimsFromContext(c)
+	jti, okJTI := helper.ClaimString(claims, jwtutil.JTIClaim)
+	expiresAt, okExp := helper.ClaimInt64(claims, jwtutil.ExpiresAtClaim)
+	if userID == -1 || !okJTI || !okExp {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// bind request, the body is optional
+	var req logoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			logger.Error(errorset.ErrBindRequest, sl.Err(err))
+			response.BindError(c, err)
+			return
+		}
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userID), slog.String(helper.JTIKey, jti))
+
+	// action with db
+	if err := a.db.RevokeAccessToken(c.Request.Context(), jti, userID, time.Unix(expiresAt, 0)); err != nil {
+		handleLogoutError(c, logger, err)
+		return
+	}
+	a.checker.RevokeToken(jti, time.Unix(expiresAt, 0))
+
+	if req.RefreshToken != "" {
+		refreshToken, err := a.db.GetRefreshTokenByHash(c.Request.Context(), refreshtoken.Hash(req.RefreshToken))
+		switch {
+		case errors.Is(err, errorset.ErrRefreshTokenNotFound):
+			logger.Warn("refresh token to revoke not found")
+		case err != nil:
+			handleLogoutError(c, logger, err)
+			return
+		case refreshToken.UserID != userID:
+			logger.Warn("refresh token to revoke belongs to another user")
+		default:
+			if err := a.db.RevokeRefreshTokenFamily(c.Request.Context(), refreshToken.FamilyID); err != nil {
+				handleLogoutError(c, logger, err)
+				return
+			}
+		}
+	}
+
+	logger.Info("user logged out successfully", slog.Int64(helper.UserIDKey, userID))
+	response.Ok(c, http.StatusOK, nil)
+}
+
+// LogoutAll implements AuthHandlers.
+// It revokes every access and refresh token issued to the user so far.
+func (a AuthHandler) LogoutAll(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.auth.AuthHandler.LogoutAll"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// fetch ID param
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userID))
+
+	// action with db
+	if err := a.db.RevokeUserTokens(c.Request.Context(), userID); err != nil {
+		handleLogoutError(c, logger, err)
+		return
+	}
+	a.checker.RevokeUser(userID, time.Now())
+
+	logger.Info("user logged out of all sessions successfully", slog.Int64(helper.UserIDKey, userID))
+	response.Ok(c, http.StatusOK, nil)
+}
+
+func handleLogoutError(c *gin.Context, log *slog.Logger, err error) {
+	if errors.Is(err, errorset.ErrUserNotFound) {
+		log.Error(err.Error(), sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	log.Error("failed to log out user", sl.Err(err))
+	response.Error(c, err)
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/refreshtoken"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Refresh implements AuthHandlers.
+func (a AuthHandler) Refresh(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.auth.AuthHandler.Refresh"
+	logger := helper.LoadLogger(a.log, c, op)
+
+	// bind request
+	var req refreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	rawRefreshToken, refreshTokenHash, err := refreshtoken.Generate()
+	if err != nil {
+		logger.Error("failed to generate refresh token", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	// action with db
+	rotated, err := a.db.RotateRefreshToken(
+		c.Request.Context(),
+		refreshtoken.Hash(req.RefreshToken),
+		refreshTokenHash,
+		time.Now().Add(a.cfg.RefreshTokenTTL),
+	)
+	if err != nil {
+		handleRefreshError(c, logger, err)
+		return
+	}
+
+	// the role may have changed since the refresh token was issued
+	userObject, err := a.db.GetUserByID(c.Request.Context(), rotated.UserID)
+	if err != nil {
+		handleRefreshError(c, logger, err)
+		return
+	}
+
+	if userObject.Disabled() {
+		handleRefreshError(c, logger, errorset.ErrUserDisabled)
+		return
+	}
+
+	accessToken, err := a.tokens.GenerateJWT(userObject.UserID, userObject.Role, a.cfg.AccessTokenTTL)
+	if err != nil {
+		logger.Error("failed to generate access token", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	logger.Info("refresh token rotated successfully",
+		slog.Int64(helper.UserIDKey, rotated.UserID),
+		slog.String(helper.FamilyIDKey, rotated.FamilyID))
+
+	response.Ok(c, http.StatusOK, a.tokensData(accessToken, rawRefreshToken))
+}
+
+func handleRefreshError(c *gin.Context, log *slog.Logger, err error) {
+	switch {
+	case errors.Is(err, errorset.ErrRefreshTokenReused):
+		log.Warn("refresh token reuse detected, token family revoked", sl.Err(err))
+		response.Error(c, errorset.ErrInvalidRefreshToken)
+	case errors.Is(err, errorset.ErrUserDisabled):
+		log.Warn(err.Error(), sl.Err(err))
+		response.Error(c, err)
+	case errors.Is(err, errorset.ErrRefreshTokenNotFound), errors.Is(err, errorset.ErrRefreshTokenExpired), errors.Is(err, errorset.ErrUserNotFound):
+		log.Warn(errorset.ErrInvalidRefreshToken.Error(), sl.Err(err))
+		response.Error(c, errorset.ErrInvalidRefreshToken)
+	default:
+		log.Error("failed to refresh token", sl.Err(err))
+		response.Error(c, err)
+	}
+}
@@ -0,0 +1,17 @@
+package auth
+
+import (
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/models/data"
+)
+
+// tokensData builds the response payload shared by login and refresh
+func (a AuthHandler) tokensData(accessToken, refreshToken string) data.Data {
+	var data data.Data = data.NewData()
+	data[helper.AccessTokenKey] = accessToken
+	data[helper.RefreshTokenKey] = refreshToken
+	data[helper.TokenTypeKey] = helper.BearerTokenType
+	data[helper.ExpiresInKey] = int64(a.cfg.AccessTokenTTL.Seconds())
+
+	return data
+}
@@ -2,19 +2,34 @@ package handlers
 
 import (
 	"log/slog"
+	"restapi/internal/config"
+	"restapi/internal/http-server/handlers/admin"
+	"restapi/internal/http-server/handlers/auth"
+	"restapi/internal/http-server/handlers/health"
 	"restapi/internal/http-server/handlers/task"
 	"restapi/internal/http-server/handlers/user"
+	healthcheck "restapi/internal/lib/health"
+	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/lifecycle"
+	"restapi/internal/lib/metrics"
+	"restapi/internal/lib/revocation"
 	"restapi/internal/storage"
 )
 
 type Handlers struct {
-	Task task.TaskHandlers
-	User user.UserHandlers
+	Admin  admin.AdminHandlers
+	Auth   auth.AuthHandlers
+	Health health.HealthHandlers
+	Task   task.TaskHandlers
+	User   user.UserHandlers
 }
 
-func NewHandlers(db storage.Storage, log *slog.Logger) *Handlers {
+func NewHandlers(db storage.Storage, log *slog.Logger, cfg *config.Config, tokens *jwtutil.Manager, checker *revocation.Checker, lc *lifecycle.Lifecycle, checks []healthcheck.Check, metrics *metrics.Metrics) *Handlers {
 	return &Handlers{
-		Task: task.NewTaskHandler(log, db),
-		User: user.NewUserHandler(log, db),
+		Admin:  admin.NewAdminHandler(log, db, checker),
+		Auth:   auth.NewAuthHandler(log, db, cfg.JWT, tokens, checker),
+		Health: health.NewHealthHandler(log, lc, checks, cfg.Health.Timeout),
+		Task:   task.NewTaskHandler(log, db, cfg.Tasks, metrics),
+		User:   user.NewUserHandler(log, db, checker, metrics),
 	}
 }
@@ -0,0 +1,65 @@
+package health
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/health"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Liveness implements HealthHandlers. It answers as long as the process serves requests.
+func (h HealthHandler) Liveness(c *gin.Context) {
+	var data data.Data = data.NewData()
+	data[helper.StatusKey] = "alive"
+
+	response.Ok(c, http.StatusOK, data)
+}
+
+// Readiness implements HealthHandlers. It fails while shutting down or if a dependency check fails.
+func (h HealthHandler) Readiness(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.health.HealthHandler.Readiness"
+	logger := helper.LoadLogger(h.log, c, op)
+
+	if !h.lc.Ready() {
+		response.Error(c, errorset.ErrShuttingDown)
+		return
+	}
+
+	report := health.Run(c.Request.Context(), h.timeout, h.checks)
+	if failed := report.Failed(); len(failed) > 0 {
+		logger.Warn("not ready", slog.Any("report", report))
+		response.Error(c, fmt.Errorf("%w: %s", errorset.ErrNotReady, strings.Join(failed, ", ")))
+		return
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.StatusKey] = "ready"
+
+	response.Ok(c, http.StatusOK, data)
+}
+
+// Health implements HealthHandlers. It reports the status and latency of every dependency.
+func (h HealthHandler) Health(c *gin.Context) {
+	report := health.Run(c.Request.Context(), h.timeout, h.checks)
+
+	code := http.StatusOK
+	if report.Status != health.StatusUp || !h.lc.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.StatusKey] = report.Status
+	data[helper.ReadyKey] = h.lc.Ready()
+	data[helper.ChecksKey] = report.Checks
+
+	response.Ok(c, code, data)
+}
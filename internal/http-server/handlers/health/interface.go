@@ -0,0 +1,33 @@
+package health
+
+import (
+	"log/slog"
+	"time"
+
+	"restapi/internal/lib/health"
+	"restapi/internal/lib/lifecycle"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthHandlers interface {
+	Liveness(c *gin.Context)
+	Readiness(c *gin.Context)
+	Health(c *gin.Context)
+}
+
+type HealthHandler struct {
+	log     *slog.Logger
+	lc      *lifecycle.Lifecycle
+	checks  []health.Check
+	timeout time.Duration
+}
+
+func NewHealthHandler(log *slog.Logger, lc *lifecycle.Lifecycle, checks []health.Check, timeout time.Duration) HealthHandlers {
+	return HealthHandler{
+		log:     log,
+		lc:      lc,
+		checks:  checks,
+		timeout: timeout,
+	}
+}
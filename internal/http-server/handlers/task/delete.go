@@ -18,23 +18,41 @@ func (t TaskHandler) DeleteTask(c *gin.Context) {
 	const op = "handlers.task.TaskHandler.DeleteTask"
 	logger := helper.LoadLogger(t.log, c, op)
 
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
 	// fetch ID param
 	taskId := helper.GetIDFromParams(c, helper.TaskIDKey)
 	if taskId == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidPathParameter)
 		return
 	}
 
 	logger.Info("decoded request", slog.Int64(helper.TaskIDKey, taskId))
 
+	// check precondition
+	version, err := t.ifMatchVersion(c, userID, taskId)
+	if err != nil {
+		handleDeletingTaskError(c, logger, err)
+		return
+	}
+
 	// action with db
-	err := t.db.DeleteTask(taskId)
+	err = t.db.DeleteTask(c.Request.Context(), userID, taskId, version)
 	if err != nil {
-		logger.Error("failed to delete task", sl.Err(err))
-		response.Error(c, http.StatusInternalServerError, "failed to delete task")
+		handleDeletingTaskError(c, logger, err)
 		return
 	}
 
-	logger.Info("task deleted successfully", slog.Int64(helper.TaskIDKey, taskId))
+	logger.Info("task moved to trash successfully", slog.Int64(helper.TaskIDKey, taskId))
 	response.Ok(c, http.StatusOK, nil)
 }
+
+func handleDeletingTaskError(c *gin.Context, log *slog.Logger, err error) {
+	log.Error("failed to delete task", sl.Err(err))
+	response.Error(c, err)
+}
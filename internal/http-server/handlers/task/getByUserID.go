@@ -24,36 +24,44 @@ func (t TaskHandler) GetTasksByUserID(c *gin.Context) {
 	// fetch ID param
 	userId := helper.FetchIDFromToken(c, helper.UserIDKey)
 	if userId == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// parse query
+	query, err := task.ParseQuery(c.Request.URL.Query())
+	if err != nil {
+		logger.Warn("invalid task query", sl.Err(err))
+		response.Error(c, err)
 		return
 	}
 
 	logger.Info("decoded request", slog.Any(helper.UserIDKey, userId))
 
 	// action with db
-	tasksSlice, err := t.db.GetTasksByUserID(userId)
-	if err != nil || len(tasksSlice) == 0 {
-		handleGettingTasksError(c, logger, err, tasksSlice, userId)
+	page, err := t.db.GetTasksByUserID(c.Request.Context(), userId, query)
+	if err != nil {
+		handleGettingTasksError(c, logger, err)
 		return
 	}
 
 	var data data.Data = data.NewData()
-	data[helper.TasksKey] = tasksSlice
+	data[helper.TasksKey] = page.Tasks
+	if page.NextCursor != "" {
+		data[helper.NextCursorKey] = page.NextCursor
+	}
 
-	logger.Info("tasks succesfully passed", slog.Int64(helper.UserIDKey, userId))
+	logger.Info("tasks succesfully passed", slog.Int64(helper.UserIDKey, userId), slog.Int("count", len(page.Tasks)))
 	response.Ok(c, http.StatusOK, data)
 }
 
-func handleGettingTasksError(c *gin.Context, log *slog.Logger, err error, tasksSlice []*task.Task, userId int64) {
+func handleGettingTasksError(c *gin.Context, log *slog.Logger, err error) {
 	if errors.Is(err, errorset.ErrUserNotFound) {
 		log.Error(err.Error(), sl.Err(err))
-		response.Error(c, http.StatusNotFound, err.Error())
+		response.Error(c, err)
 		return
 	}
 
-	if len(tasksSlice) == 0 {
-		log.Warn("no tasks found for user", slog.Int64(helper.UserIDKey, userId))
-		response.Error(c, http.StatusNotFound, errorset.ErrTaskNotFound.Error())
-		return
-	}
+	log.Error("failed to get tasks", sl.Err(err))
+	response.Error(c, err)
 }
@@ -1,11 +1,11 @@
 package task
 
 import (
-	"errors"
 	"log/slog"
 	"net/http"
 
 	"restapi/internal/errorset"
+	"restapi/internal/lib/etag"
 	helper "restapi/internal/lib/helperfunctions"
 	"restapi/internal/lib/sl"
 	"restapi/internal/models/data"
@@ -20,22 +20,36 @@ func (t TaskHandler) GetTaskByTaskID(c *gin.Context) {
 	const op = "handlers.task.TaskHandler.GetTaskByTaskID"
 	logger := helper.LoadLogger(t.log, c, op)
 
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
 	// fetch ID param
 	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
 	if taskID == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidPathParameter)
 		return
 	}
 
 	logger.Info("decoded request", slog.Any("req", taskID))
 
 	// action with db
-	task, err := t.db.GetTaskByTaskID(taskID)
+	task, err := t.db.GetTaskByTaskID(c.Request.Context(), userID, taskID)
 	if err != nil {
 		handleGettingTaskError(c, logger, err)
 		return
 	}
 
+	// the client's copy is still current
+	c.Header(etag.Header, etag.Format(task.Version))
+	if !etag.NoneMatch(c.GetHeader(etag.IfNoneMatchHeader), task.Version) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	var data data.Data = data.NewData()
 	data[helper.TaskKey] = task
 
@@ -48,10 +62,6 @@ func (t TaskHandler) GetTaskByTaskID(c *gin.Context) {
 
 func handleGettingTaskError(c *gin.Context, log *slog.Logger, err error) {
 	log.Error("failed to get task", sl.Err(err))
-	if errors.Is(err, errorset.ErrTaskNotFound) {
-		response.Error(c, http.StatusNotFound, err.Error())
-		return
-	}
-
-	response.Error(c, http.StatusInternalServerError, "failed to get task")
+	// tasks of other users are reported as not found, so their IDs are not disclosed
+	response.Error(c, err)
 }
@@ -2,7 +2,10 @@ package task
 
 import (
 	"log/slog"
+	"restapi/internal/config"
+	"restapi/internal/lib/metrics"
 	"restapi/internal/storage"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,22 +14,54 @@ type TaskHandlers interface {
 	DeleteTask(c *gin.Context)
 	GetTaskByTaskID(c *gin.Context)
 	GetTasksByUserID(c *gin.Context)
+	SearchTasks(c *gin.Context)
 	UpdateTask(c *gin.Context)
+	UpdateTaskStatus(c *gin.Context)
+	ToggleTaskCompletion(c *gin.Context)
+	UpdateTaskDueAt(c *gin.Context)
+	UpdateTaskPriority(c *gin.Context)
+	PatchTask(c *gin.Context)
+	GetTrashedTasks(c *gin.Context)
+	RestoreTask(c *gin.Context)
+	PurgeTask(c *gin.Context)
 	SaveTask(c *gin.Context)
 }
 
 type TaskHandler struct {
-	log *slog.Logger
-	db  storage.Storage
+	log     *slog.Logger
+	db      storage.Storage
+	cfg     config.TasksConfig
+	metrics *metrics.Metrics
 }
 
-func NewTaskHandler(log *slog.Logger, db storage.Storage) TaskHandlers {
+func NewTaskHandler(log *slog.Logger, db storage.Storage, cfg config.TasksConfig, metrics *metrics.Metrics) TaskHandlers {
 	return TaskHandler{
-		log: log,
-		db: db,
+		log:     log,
+		db:      db,
+		cfg:     cfg,
+		metrics: metrics,
 	}
 }
 
 type request struct {
 	TaskContent string `json:"taskContent" binding:"required"`
 }
+
+type saveRequest struct {
+	TaskContent string     `json:"taskContent" binding:"required"`
+	Status      string     `json:"status" binding:"omitempty,oneof=todo in_progress done"`
+	Priority    int        `json:"priority" binding:"min=0,max=3"`
+	DueAt       *time.Time `json:"dueAt"`
+}
+
+type statusRequest struct {
+	Status string `json:"status" binding:"required,oneof=todo in_progress done"`
+}
+
+type dueAtRequest struct {
+	DueAt *time.Time `json:"dueAt"` // null clears the due date
+}
+
+type priorityRequest struct {
+	Priority *int `json:"priority" binding:"required,min=0,max=3"`
+}
@@ -0,0 +1,288 @@
+package task
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"restapi/internal/config"
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/models/task"
+	"restapi/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	ownerID    int64 = 1
+	strangerID int64 = 2
+	ownedTask  int64 = 10
+)
+
+// taskStorage keeps tasks in a map and applies the same owner scoping as the
+// real storages; methods not used by the task handlers panic via the nil interface
+type taskStorage struct {
+	storage.Storage
+	tasks map[int64]*task.Task
+}
+
+func (s *taskStorage) GetTaskByTaskID(_ context.Context, userID, taskID int64) (*task.Task, error) {
+	t, ok := s.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return nil, errorset.ErrTaskNotFound
+	}
+
+	return t, nil
+}
+
+func (s *taskStorage) UpdateTaskContent(_ context.Context, userID, taskID int64, content string, version int64) (int64, error) {
+	t, ok := s.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return 0, errorset.ErrTaskNotFound
+	}
+	if version != task.AnyVersion && version != t.Version {
+		return 0, errorset.ErrVersionMismatch
+	}
+
+	t.TaskContent = content
+	t.Version++
+	return t.Version, nil
+}
+
+func (s *taskStorage) UpdateTaskStatus(_ context.Context, userID, taskID int64, status string, version int64) (*task.Task, error) {
+	t, err := s.update(userID, taskID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Status = status
+	return t, nil
+}
+
+func (s *taskStorage) ToggleTaskCompletion(_ context.Context, userID, taskID int64, version int64) (*task.Task, error) {
+	t, err := s.update(userID, taskID, version)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.Status == task.StatusDone {
+		t.Status = task.StatusTodo
+	} else {
+		t.Status = task.StatusDone
+	}
+	return t, nil
+}
+
+func (s *taskStorage) UpdateTaskDueAt(_ context.Context, userID, taskID int64, dueAt *time.Time, version int64) (int64, error) {
+	t, err := s.update(userID, taskID, version)
+	if err != nil {
+		return 0, err
+	}
+
+	t.DueAt = dueAt
+	return t.Version, nil
+}
+
+func (s *taskStorage) UpdateTaskPriority(_ context.Context, userID, taskID int64, priority int, version int64) (int64, error) {
+	t, err := s.update(userID, taskID, version)
+	if err != nil {
+		return 0, err
+	}
+
+	t.Priority = priority
+	return t.Version, nil
+}
+
+// update checks owner and version of a task and bumps its version for a write
+func (s *taskStorage) update(userID, taskID, version int64) (*task.Task, error) {
+	t, ok := s.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return nil, errorset.ErrTaskNotFound
+	}
+	if version != task.AnyVersion && version != t.Version {
+		return nil, errorset.ErrVersionMismatch
+	}
+
+	t.Version++
+	return t, nil
+}
+
+func (s *taskStorage) PatchTask(_ context.Context, userID, taskID int64, patch task.Patch, version int64) (*task.Task, error) {
+	t, ok := s.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return nil, errorset.ErrTaskNotFound
+	}
+	if version != task.AnyVersion && version != t.Version {
+		return nil, errorset.ErrVersionMismatch
+	}
+	if patch.IsEmpty() {
+		return t, nil
+	}
+
+	if patch.TaskContent != nil {
+		t.TaskContent = *patch.TaskContent
+	}
+	if patch.Status != nil {
+		t.Status = *patch.Status
+	}
+	if patch.Priority != nil {
+		t.Priority = *patch.Priority
+	}
+	if patch.SetDueAt {
+		t.DueAt = patch.DueAt
+	}
+	t.Version++
+	return t, nil
+}
+
+func (s *taskStorage) DeleteTask(_ context.Context, userID, taskID int64, version int64) error {
+	t, ok := s.tasks[taskID]
+	if !ok || t.UserID != userID {
+		return errorset.ErrTaskNotFound
+	}
+	if version != task.AnyVersion && version != t.Version {
+		return errorset.ErrVersionMismatch
+	}
+
+	delete(s.tasks, taskID)
+	return nil
+}
+
+func setupTaskRouter(db storage.Storage, userID int64) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	handler := NewTaskHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), db, config.TasksConfig{}, nil)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(helper.ClaimsKey, jwt.MapClaims{helper.UserIDKey: float64(userID)})
+	})
+	router.GET("/tasks/:taskId", handler.GetTaskByTaskID)
+	router.PUT("/tasks/:taskId", handler.UpdateTask)
+	router.PATCH("/tasks/:taskId", handler.PatchTask)
+	router.DELETE("/tasks/:taskId", handler.DeleteTask)
+
+	return router
+}
+
+func TestTaskOwnership(t *testing.T) {
+	tests := []struct {
+		name            string
+		userID          int64
+		method          string
+		taskID          int64
+		body            string
+		expectedStatus  int
+		expectedContent string
+		expectedExists  bool
+	}{
+		{
+			name:            "owner reads task",
+			userID:          ownerID,
+			method:          http.MethodGet,
+			taskID:          ownedTask,
+			expectedStatus:  http.StatusOK,
+			expectedContent: "original",
+			expectedExists:  true,
+		},
+		{
+			name:            "stranger reads task",
+			userID:          strangerID,
+			method:          http.MethodGet,
+			taskID:          ownedTask,
+			expectedStatus:  http.StatusNotFound,
+			expectedContent: "original",
+			expectedExists:  true,
+		},
+		{
+			name:            "owner updates task",
+			userID:          ownerID,
+			method:          http.MethodPut,
+			taskID:          ownedTask,
+			body:            `{"taskContent":"changed"}`,
+			expectedStatus:  http.StatusOK,
+			expectedContent: "changed",
+			expectedExists:  true,
+		},
+		{
+			name:            "stranger updates task",
+			userID:          strangerID,
+			method:          http.MethodPut,
+			taskID:          ownedTask,
+			body:            `{"taskContent":"changed"}`,
+			expectedStatus:  http.StatusNotFound,
+			expectedContent: "original",
+			expectedExists:  true,
+		},
+		{
+			name:            "stranger patches task",
+			userID:          strangerID,
+			method:          http.MethodPatch,
+			taskID:          ownedTask,
+			body:            `{"taskContent":"changed"}`,
+			expectedStatus:  http.StatusNotFound,
+			expectedContent: "original",
+			expectedExists:  true,
+		},
+		{
+			name:           "owner deletes task",
+			userID:         ownerID,
+			method:         http.MethodDelete,
+			taskID:         ownedTask,
+			expectedStatus: http.StatusOK,
+			expectedExists: false,
+		},
+		{
+			name:            "stranger deletes task",
+			userID:          strangerID,
+			method:          http.MethodDelete,
+			taskID:          ownedTask,
+			expectedStatus:  http.StatusNotFound,
+			expectedContent: "original",
+			expectedExists:  true,
+		},
+		{
+			name:            "owner reads missing task",
+			userID:          ownerID,
+			method:          http.MethodGet,
+			taskID:          ownedTask + 1,
+			expectedStatus:  http.StatusNotFound,
+			expectedContent: "original",
+			expectedExists:  true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			db := &taskStorage{tasks: map[int64]*task.Task{
+				ownedTask: {TaskID: ownedTask, UserID: ownerID, TaskContent: "original"},
+			}}
+			router := setupTaskRouter(db, tc.userID)
+
+			req := httptest.NewRequest(tc.method, "/tasks/"+strconv.FormatInt(tc.taskID, 10), strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tc.expectedStatus, rec.Code, rec.Body.String())
+			}
+
+			stored, exists := db.tasks[ownedTask]
+			if exists != tc.expectedExists {
+				t.Fatalf("expected task to exist: %v, got %v", tc.expectedExists, exists)
+			}
+			if exists && stored.TaskContent != tc.expectedContent {
+				t.Errorf("expected content %q, got %q", tc.expectedContent, stored.TaskContent)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package task
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/etag"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/patch"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+	"restapi/internal/models/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patchDocument is a task as a patch sees it. Only the fields with binding rules
+// may change, the others are read-only.
+type patchDocument struct {
+	TaskID      int64      `json:"taskId"`
+	UserID      int64      `json:"userId"`
+	TaskContent string     `json:"taskContent" binding:"required"`
+	Status      string     `json:"status" binding:"required,oneof=todo in_progress done"`
+	Priority    int        `json:"priority" binding:"min=0,max=3"`
+	DueAt       *time.Time `json:"dueAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	Version     int64      `json:"version"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+// PatchTask implements TaskHandlers.
+func (t TaskHandler) PatchTask(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.PatchTask"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// fetch ID param
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	// read patch
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, patch.MaxSize))
+	if err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.String("content_type", c.ContentType()), slog.Int("size", len(body)))
+
+	// check precondition
+	version, err := t.ifMatchVersion(c, userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	// the patch applies to the current task, which must be at the version asked for
+	current, err := t.db.GetTaskByTaskID(c.Request.Context(), userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+	if version != task.AnyVersion && current.Version != version {
+		handleUpdatingTaskError(c, logger, errorset.ErrVersionMismatch)
+		return
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	patched, err := patch.Apply(c.ContentType(), doc, body)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	// validate the patched task
+	var result patchDocument
+	if err := patch.Bind(patched, &result); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+	if readOnly := readOnlyChanges(current, result); len(readOnly) > 0 {
+		logger.Error(errorset.ErrBindRequest, sl.Err(readOnly))
+		response.BindError(c, readOnly)
+		return
+	}
+
+	// action with db: only the changed fields are written, at the version the patch applied to
+	changes := changedFields(current, result)
+	updated, err := t.db.PatchTask(c.Request.Context(), userID, taskID, changes, current.Version)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	if changes.Status != nil && *changes.Status == task.StatusDone {
+		t.metrics.TaskCompleted()
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.TaskKey] = updated
+
+	logger.Info("task patched successfully", slog.Int64(helper.TaskIDKey, taskID))
+	c.Header(etag.Header, etag.Format(updated.Version))
+	response.Ok(c, http.StatusOK, data)
+}
+
+// readOnlyChanges lists the read-only fields the patch changed
+func readOnlyChanges(current *task.Task, result patchDocument) response.FieldErrors {
+	return response.ReadOnlyErrors(
+		response.ReadOnlyField{Name: "taskId", Changed: result.TaskID != current.TaskID},
+		response.ReadOnlyField{Name: "userId", Changed: result.UserID != current.UserID},
+		response.ReadOnlyField{Name: "completedAt", Changed: !equalTime(result.CompletedAt, current.CompletedAt)},
+		response.ReadOnlyField{Name: "createdAt", Changed: !result.CreatedAt.Equal(current.CreatedAt)},
+		response.ReadOnlyField{Name: "updatedAt", Changed: !result.UpdatedAt.Equal(current.UpdatedAt)},
+		response.ReadOnlyField{Name: "version", Changed: result.Version != current.Version},
+		response.ReadOnlyField{Name: "deletedAt", Changed: !equalTime(result.DeletedAt, current.DeletedAt)},
+	)
+}
+
+// changedFields returns the patch of the fields that differ from the current task
+func changedFields(current *task.Task, result patchDocument) task.Patch {
+	var changes task.Patch
+	if result.TaskContent != current.TaskContent {
+		changes.TaskContent = &result.TaskContent
+	}
+	if result.Status != current.Status {
+		changes.Status = &result.Status
+	}
+	if result.Priority != current.Priority {
+		changes.Priority = &result.Priority
+	}
+	if !equalTime(result.DueAt, current.DueAt) {
+		changes.SetDueAt = true
+		changes.DueAt = result.DueAt
+	}
+
+	return changes
+}
+
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Equal(*b)
+}
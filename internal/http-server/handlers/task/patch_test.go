@@ -0,0 +1,173 @@
+package task
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"restapi/internal/lib/etag"
+	"restapi/internal/lib/patch"
+	"restapi/internal/models/response"
+	"restapi/internal/models/task"
+)
+
+func TestPatchTask(t *testing.T) {
+	created := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		contentType string
+		ifMatch     string
+		body        string
+		wantStatus  int
+		wantCode    string
+		wantField   string
+		check       func(t *testing.T, stored *task.Task)
+	}{
+		{
+			name:        "merge patch changes given fields only",
+			contentType: patch.MergePatchType,
+			body:        `{"status":"in_progress","priority":3}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, stored *task.Task) {
+				if stored.Status != task.StatusInProgress || stored.Priority != task.PriorityHigh || stored.TaskContent != "original" {
+					t.Errorf("stored task %+v, want in progress, high priority, original content", stored)
+				}
+			},
+		},
+		{
+			name:        "merge patch with null clears due date",
+			contentType: patch.MergePatchType,
+			body:        `{"dueAt":null}`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, stored *task.Task) {
+				if stored.DueAt != nil {
+					t.Errorf("due date %v, want cleared", stored.DueAt)
+				}
+			},
+		},
+		{
+			name:        "json patch with passing test",
+			contentType: patch.JSONPatchType,
+			ifMatch:     `"1"`,
+			body:        `[{"op":"test","path":"/version","value":1},{"op":"replace","path":"/taskContent","value":"changed"}]`,
+			wantStatus:  http.StatusOK,
+			check: func(t *testing.T, stored *task.Task) {
+				if stored.TaskContent != "changed" || stored.Version != 2 {
+					t.Errorf("stored task %+v, want changed content at version 2", stored)
+				}
+			},
+		},
+		{
+			name:        "json patch with failing test",
+			contentType: patch.JSONPatchType,
+			body:        `[{"op":"test","path":"/taskContent","value":"other"},{"op":"remove","path":"/dueAt"}]`,
+			wantStatus:  http.StatusConflict,
+			wantCode:    "patch_test_failed",
+		},
+		{
+			name:        "plain json",
+			contentType: "application/json",
+			body:        `{"priority":1}`,
+			wantStatus:  http.StatusUnsupportedMediaType,
+			wantCode:    "unsupported_media_type",
+		},
+		{
+			name:        "malformed patch",
+			contentType: patch.JSONPatchType,
+			body:        `[{"op":"replace","path":"/priority"`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "invalid_patch",
+		},
+		{
+			name:        "invalid result",
+			contentType: patch.MergePatchType,
+			body:        `{"status":"later"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "validation_failed",
+			wantField:   "status",
+		},
+		{
+			name:        "removed required field",
+			contentType: patch.JSONPatchType,
+			body:        `[{"op":"remove","path":"/taskContent"}]`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "validation_failed",
+			wantField:   "taskContent",
+		},
+		{
+			name:        "read-only field",
+			contentType: patch.MergePatchType,
+			body:        `{"createdAt":"2020-01-01T00:00:00Z"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "validation_failed",
+			wantField:   "createdAt",
+		},
+		{
+			name:        "unknown field",
+			contentType: patch.MergePatchType,
+			body:        `{"color":"red"}`,
+			wantStatus:  http.StatusBadRequest,
+			wantCode:    "validation_failed",
+			wantField:   "color",
+		},
+		{
+			name:        "stale version",
+			contentType: patch.MergePatchType,
+			ifMatch:     `"7"`,
+			body:        `{"priority":1}`,
+			wantStatus:  http.StatusPreconditionFailed,
+			wantCode:    "version_mismatch",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			due := created.Add(24 * time.Hour)
+			db := &taskStorage{tasks: map[int64]*task.Task{
+				ownedTask: {TaskID: ownedTask, UserID: ownerID, TaskContent: "original", Status: task.StatusTodo,
+					DueAt: &due, CreatedAt: created, UpdatedAt: created, Version: 1},
+			}}
+			router := setupTaskRouter(db, ownerID)
+
+			req := httptest.NewRequest(http.MethodPatch, "/tasks/10", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			if tc.ifMatch != "" {
+				req.Header.Set(etag.IfMatchHeader, tc.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+
+			stored := db.tasks[ownedTask]
+			if tc.wantStatus == http.StatusOK {
+				if got, want := rec.Header().Get(etag.Header), etag.Format(stored.Version); got != want {
+					t.Errorf("ETag %q, want %q", got, want)
+				}
+				tc.check(t, stored)
+				return
+			}
+
+			if stored.Version != 1 {
+				t.Errorf("failed patch was stored: version %d", stored.Version)
+			}
+
+			var problem response.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if problem.Code != tc.wantCode {
+				t.Errorf("code %q, want %q", problem.Code, tc.wantCode)
+			}
+			if tc.wantField != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tc.wantField) {
+				t.Errorf("field errors %+v, want one for %s", problem.Errors, tc.wantField)
+			}
+		})
+	}
+}
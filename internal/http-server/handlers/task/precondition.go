@@ -0,0 +1,43 @@
+package task
+
+import (
+	"restapi/internal/errorset"
+	"restapi/internal/lib/etag"
+	"restapi/internal/models/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ifMatchVersion returns the version of the task the If-Match header of the request allows to
+// write. Without the header any version may be written, unless the config requires it.
+func (t TaskHandler) ifMatchVersion(c *gin.Context, userID, taskID int64) (int64, error) {
+	header := c.GetHeader(etag.IfMatchHeader)
+	if header == "" {
+		if t.cfg.RequireIfMatch {
+			return 0, errorset.ErrPreconditionRequired
+		}
+		return task.AnyVersion, nil
+	}
+
+	precondition := etag.ParseIfMatch(header)
+	switch {
+	case precondition.Any:
+		return task.AnyVersion, nil
+	case len(precondition.Versions) == 0:
+		return 0, errorset.ErrVersionMismatch
+	case len(precondition.Versions) == 1:
+		return precondition.Versions[0], nil
+	}
+
+	// one of several versions may be written: pick the current one, the storage still
+	// refuses the write if the task changes before it
+	current, err := t.db.GetTaskByTaskID(c.Request.Context(), userID, taskID)
+	if err != nil {
+		return 0, err
+	}
+	if !precondition.Matches(current.Version) {
+		return 0, errorset.ErrVersionMismatch
+	}
+
+	return current.Version, nil
+}
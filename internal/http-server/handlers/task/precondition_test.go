@@ -0,0 +1,169 @@
+package task
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"restapi/internal/config"
+	"restapi/internal/lib/etag"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/models/task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestConditionalRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	db := &taskStorage{tasks: map[int64]*task.Task{
+		ownedTask: {TaskID: ownedTask, UserID: ownerID, TaskContent: "original", Version: 1},
+	}}
+
+	newRouter := func(cfg config.TasksConfig) *gin.Engine {
+		handler := NewTaskHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), db, cfg, nil)
+
+		router := gin.New()
+		router.Use(func(c *gin.Context) {
+			c.Set(helper.ClaimsKey, jwt.MapClaims{helper.UserIDKey: float64(ownerID)})
+		})
+		router.GET("/tasks/:taskId", handler.GetTaskByTaskID)
+		router.PUT("/tasks/:taskId", handler.UpdateTask)
+		router.DELETE("/tasks/:taskId", handler.DeleteTask)
+
+		return router
+	}
+	lenient := newRouter(config.TasksConfig{})
+	strict := newRouter(config.TasksConfig{RequireIfMatch: true})
+
+	steps := []struct {
+		name       string
+		router     *gin.Engine
+		method     string
+		header     string
+		value      string
+		wantStatus int
+		wantETag   string
+	}{
+		{"read", lenient, http.MethodGet, "", "", http.StatusOK, `"1"`},
+		{"read unchanged", lenient, http.MethodGet, etag.IfNoneMatchHeader, `W/"1"`, http.StatusNotModified, `"1"`},
+		{"update current version", lenient, http.MethodPut, etag.IfMatchHeader, `"1"`, http.StatusOK, `"2"`},
+		{"update stale version", lenient, http.MethodPut, etag.IfMatchHeader, `"1"`, http.StatusPreconditionFailed, ""},
+		{"read changed", lenient, http.MethodGet, etag.IfNoneMatchHeader, `"1"`, http.StatusOK, `"2"`},
+		{"update one of several versions", lenient, http.MethodPut, etag.IfMatchHeader, `"1", "2"`, http.StatusOK, `"3"`},
+		{"update without If-Match", lenient, http.MethodPut, "", "", http.StatusOK, `"4"`},
+		{"update without required If-Match", strict, http.MethodPut, "", "", http.StatusPreconditionRequired, ""},
+		{"delete with weak tag", strict, http.MethodDelete, etag.IfMatchHeader, `W/"4"`, http.StatusPreconditionFailed, ""},
+		{"delete current version", strict, http.MethodDelete, etag.IfMatchHeader, `"4"`, http.StatusOK, ""},
+	}
+
+	for _, step := range steps {
+		var body io.Reader
+		if step.method == http.MethodPut {
+			body = strings.NewReader(`{"taskContent":"` + step.name + `"}`)
+		}
+
+		req := httptest.NewRequest(step.method, "/tasks/10", body)
+		req.Header.Set("Content-Type", "application/json")
+		if step.header != "" {
+			req.Header.Set(step.header, step.value)
+		}
+
+		rec := httptest.NewRecorder()
+		step.router.ServeHTTP(rec, req)
+
+		if rec.Code != step.wantStatus {
+			t.Fatalf("%s: status %d, want %d: %s", step.name, rec.Code, step.wantStatus, rec.Body.String())
+		}
+		if got := rec.Header().Get(etag.Header); got != step.wantETag {
+			t.Fatalf("%s: ETag %q, want %q", step.name, got, step.wantETag)
+		}
+		if step.wantStatus == http.StatusNotModified && rec.Body.Len() != 0 {
+			t.Fatalf("%s: 304 with body %q", step.name, rec.Body.String())
+		}
+	}
+
+	if _, exists := db.tasks[ownedTask]; exists {
+		t.Fatal("task not deleted")
+	}
+}
+
+func TestConditionalFieldUpdates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	routes := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPut, "/tasks/:taskId/status", `{"status":"done"}`},
+		{http.MethodPost, "/tasks/:taskId/toggle", ""},
+		{http.MethodPut, "/tasks/:taskId/due", `{"dueAt":"2030-01-02T03:04:05Z"}`},
+		{http.MethodPut, "/tasks/:taskId/priority", `{"priority":2}`},
+	}
+
+	for _, route := range routes {
+		t.Run(route.path, func(t *testing.T) {
+			db := &taskStorage{tasks: map[int64]*task.Task{
+				ownedTask: {TaskID: ownedTask, UserID: ownerID, TaskContent: "original", Status: task.StatusTodo, Version: 1},
+			}}
+
+			newRouter := func(cfg config.TasksConfig) *gin.Engine {
+				handler := NewTaskHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), db, cfg, nil)
+
+				router := gin.New()
+				router.Use(func(c *gin.Context) {
+					c.Set(helper.ClaimsKey, jwt.MapClaims{helper.UserIDKey: float64(ownerID)})
+				})
+				router.PUT("/tasks/:taskId/status", handler.UpdateTaskStatus)
+				router.POST("/tasks/:taskId/toggle", handler.ToggleTaskCompletion)
+				router.PUT("/tasks/:taskId/due", handler.UpdateTaskDueAt)
+				router.PUT("/tasks/:taskId/priority", handler.UpdateTaskPriority)
+
+				return router
+			}
+			lenient := newRouter(config.TasksConfig{})
+			strict := newRouter(config.TasksConfig{RequireIfMatch: true})
+
+			steps := []struct {
+				name       string
+				router     *gin.Engine
+				ifMatch    string
+				wantStatus int
+				wantETag   string
+			}{
+				{"current version", lenient, `"1"`, http.StatusOK, `"2"`},
+				{"stale version", lenient, `"1"`, http.StatusPreconditionFailed, ""},
+				{"without If-Match", lenient, "", http.StatusOK, `"3"`},
+				{"without required If-Match", strict, "", http.StatusPreconditionRequired, ""},
+				{"required If-Match", strict, `"3"`, http.StatusOK, `"4"`},
+			}
+
+			for _, step := range steps {
+				req := httptest.NewRequest(route.method, strings.Replace(route.path, ":taskId", "10", 1), strings.NewReader(route.body))
+				req.Header.Set("Content-Type", "application/json")
+				if step.ifMatch != "" {
+					req.Header.Set(etag.IfMatchHeader, step.ifMatch)
+				}
+
+				rec := httptest.NewRecorder()
+				step.router.ServeHTTP(rec, req)
+
+				if rec.Code != step.wantStatus {
+					t.Fatalf("%s: status %d, want %d: %s", step.name, rec.Code, step.wantStatus, rec.Body.String())
+				}
+				if got := rec.Header().Get(etag.Header); got != step.wantETag {
+					t.Fatalf("%s: ETag %q, want %q", step.name, got, step.wantETag)
+				}
+			}
+
+			if version := db.tasks[ownedTask].Version; version != 4 {
+				t.Fatalf("task at version %d, want 4", version)
+			}
+		})
+	}
+}
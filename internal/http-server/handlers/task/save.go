@@ -1,6 +1,7 @@
 package task
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -9,6 +10,7 @@ import (
 	"restapi/internal/lib/sl"
 	"restapi/internal/models/data"
 	"restapi/internal/models/response"
+	"restapi/internal/models/task"
 
 	"github.com/gin-gonic/gin"
 )
@@ -22,27 +24,38 @@ func (t TaskHandler) SaveTask(c *gin.Context) {
 	// fetch ID param
 	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
 	if userID == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidTokenClaims)
 		return
 	}
 
 	// bind request
-	var req request
+	var req saveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error(errorset.ErrBindRequest, sl.Err(err))
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.BindError(c, err)
 		return
 	}
 
 	logger.Info("decoded request", slog.Any(helper.ReqKey, nil))
 
 	// action with db
-	taskId, err := t.db.SaveTask(userID, req.TaskContent)
+	taskId, err := t.db.SaveTask(c.Request.Context(), &task.Task{
+		UserID:      userID,
+		TaskContent: req.TaskContent,
+		Status:      req.Status,
+		Priority:    req.Priority,
+		DueAt:       req.DueAt,
+	})
 	if err != nil {
 		handleSavingTaskError(c, logger, err, taskId)
 		return
 	}
 
+	t.metrics.TaskCreated()
+	if req.Status == task.StatusDone {
+		t.metrics.TaskCompleted()
+	}
+
 	var data data.Data = data.NewData()
 	data[helper.TaskIDKey] = taskId
 
@@ -54,12 +67,12 @@ func (t TaskHandler) SaveTask(c *gin.Context) {
 
 func handleSavingTaskError(c *gin.Context, log *slog.Logger, err error, taskId int64) {
 	log.Error("failed to save task", sl.Err(err))
-	if err == errorset.ErrUserNotFound {
-		response.Error(c, http.StatusConflict, errorset.ErrUserNotFound.Error())
+	if errors.Is(err, errorset.ErrUserNotFound) {
+		response.Error(c, errorset.ErrUserNotFound)
 		return
 	} else if taskId == 0 {
 		log.Error("unexpected task ID = 0 after saving task")
-		response.Error(c, http.StatusInternalServerError, "unexpected server error")
+		response.Error(c, err)
 		return
 	}
 }
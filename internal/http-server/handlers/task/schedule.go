@@ -0,0 +1,112 @@
+package task
+
+import (
+	"log/slog"
+	"net/http"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/etag"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateTaskDueAt implements TaskHandlers.
+func (t TaskHandler) UpdateTaskDueAt(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.UpdateTaskDueAt"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// fetch ID param
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	// bind request
+	var req dueAtRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.Any(helper.ReqKey, req))
+
+	// check precondition
+	version, err := t.ifMatchVersion(c, userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	// action with db
+	version, err = t.db.UpdateTaskDueAt(c.Request.Context(), userID, taskID, req.DueAt, version)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	logger.Info("task due date updated successfully", slog.Int64(helper.TaskIDKey, taskID))
+	c.Header(etag.Header, etag.Format(version))
+	response.Ok(c, http.StatusOK, nil)
+}
+
+// UpdateTaskPriority implements TaskHandlers.
+func (t TaskHandler) UpdateTaskPriority(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.UpdateTaskPriority"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// fetch ID param
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	// bind request
+	var req priorityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int(helper.PriorityKey, *req.Priority))
+
+	// check precondition
+	version, err := t.ifMatchVersion(c, userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	// action with db
+	version, err = t.db.UpdateTaskPriority(c.Request.Context(), userID, taskID, *req.Priority, version)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	logger.Info("task priority updated successfully", slog.Int64(helper.TaskIDKey, taskID))
+	c.Header(etag.Header, etag.Format(version))
+	response.Ok(c, http.StatusOK, nil)
+}
@@ -0,0 +1,52 @@
+package task
+
+import (
+	"log/slog"
+	"net/http"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+	"restapi/internal/models/task"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SearchTasks implements TaskHandlers.
+func (t TaskHandler) SearchTasks(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.SearchTasks"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userId := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userId == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// parse query
+	query, err := task.ParseSearchQuery(c.Request.URL.Query())
+	if err != nil {
+		logger.Warn("invalid search query", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userId), slog.Any("terms", query.Terms))
+
+	// action with db
+	results, err := t.db.SearchTasks(c.Request.Context(), userId, query)
+	if err != nil {
+		handleGettingTasksError(c, logger, err)
+		return
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.ResultsKey] = results
+
+	logger.Info("tasks succesfully searched", slog.Int64(helper.UserIDKey, userId), slog.Int("count", len(results)))
+	response.Ok(c, http.StatusOK, data)
+}
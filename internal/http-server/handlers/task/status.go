@@ -0,0 +1,120 @@
+package task
+
+import (
+	"log/slog"
+	"net/http"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/etag"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateTaskStatus implements TaskHandlers.
+func (t TaskHandler) UpdateTaskStatus(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.UpdateTaskStatus"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// fetch ID param
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	// bind request
+	var req statusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.Any(helper.ReqKey, req))
+
+	// check precondition
+	version, err := t.ifMatchVersion(c, userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	// action with db
+	updated, err := t.db.UpdateTaskStatus(c.Request.Context(), userID, taskID, req.Status, version)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	// setting done again doesn't complete the task again
+	if updated.Completed() {
+		t.metrics.TaskCompleted()
+	}
+
+	logger.Info("task status updated successfully", slog.Int64(helper.TaskIDKey, taskID))
+	c.Header(etag.Header, etag.Format(updated.Version))
+	response.Ok(c, http.StatusOK, nil)
+}
+
+// ToggleTaskCompletion implements TaskHandlers.
+func (t TaskHandler) ToggleTaskCompletion(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.ToggleTaskCompletion"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// fetch ID param
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.TaskIDKey, taskID))
+
+	// check precondition
+	version, err := t.ifMatchVersion(c, userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	// action with db
+	task, err := t.db.ToggleTaskCompletion(c.Request.Context(), userID, taskID, version)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	if task.Completed() {
+		t.metrics.TaskCompleted()
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.TaskKey] = task
+
+	logger.Info("task completion toggled successfully",
+		slog.Int64(helper.TaskIDKey, taskID),
+		slog.String(helper.StatusKey, task.Status))
+
+	c.Header(etag.Header, etag.Format(task.Version))
+	response.Ok(c, http.StatusOK, data)
+}
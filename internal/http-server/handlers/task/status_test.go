@@ -0,0 +1,69 @@
+package task
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"restapi/internal/config"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/metrics"
+	"restapi/internal/models/memory"
+	"restapi/internal/models/task"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+type noPool struct{}
+
+func (noPool) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
+
+func TestUpdateTaskStatusCountsCompletionOnce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	db := memory.NewMemory()
+	userID, err := db.SaveUser(ctx, "alice", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskID, err := db.SaveTask(ctx, &task.Task{UserID: userID, TaskContent: "buy milk"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := metrics.New(noPool{})
+	handler := NewTaskHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), db, config.TasksConfig{}, m)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(helper.ClaimsKey, jwt.MapClaims{helper.UserIDKey: float64(userID)})
+	})
+	router.PUT("/tasks/:taskId/status", handler.UpdateTaskStatus)
+
+	for _, status := range []string{task.StatusDone, task.StatusDone, task.StatusInProgress, task.StatusDone} {
+		req := httptest.NewRequest(http.MethodPut, "/tasks/"+strconv.FormatInt(taskID, 10)+"/status", strings.NewReader(`{"status":"`+status+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %s answered %d: %s", status, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if !strings.Contains(rec.Body.String(), "restapi_tasks_completed_total 2") {
+		t.Fatal("want 2 completions counted, done set twice in a row counts once")
+	}
+}
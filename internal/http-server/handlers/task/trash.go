@@ -0,0 +1,112 @@
+package task
+
+import (
+	"log/slog"
+	"net/http"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/etag"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetTrashedTasks implements TaskHandlers.
+func (t TaskHandler) GetTrashedTasks(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.GetTrashedTasks"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.UserIDKey, userID))
+
+	// action with db
+	tasks, err := t.db.GetTrashedTasks(c.Request.Context(), userID)
+	if err != nil {
+		handleGettingTasksError(c, logger, err)
+		return
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.TasksKey] = tasks
+
+	logger.Info("trashed tasks succesfully passed", slog.Int64(helper.UserIDKey, userID), slog.Int("count", len(tasks)))
+	response.Ok(c, http.StatusOK, data)
+}
+
+// RestoreTask implements TaskHandlers.
+func (t TaskHandler) RestoreTask(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.RestoreTask"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// fetch ID param
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.TaskIDKey, taskID))
+
+	// action with db
+	task, err := t.db.RestoreTask(c.Request.Context(), userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.TaskKey] = task
+
+	logger.Info("task restored successfully", slog.Int64(helper.TaskIDKey, taskID))
+	c.Header(etag.Header, etag.Format(task.Version))
+	response.Ok(c, http.StatusOK, data)
+}
+
+// PurgeTask implements TaskHandlers.
+func (t TaskHandler) PurgeTask(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.task.TaskHandler.PurgeTask"
+	logger := helper.LoadLogger(t.log, c, op)
+
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// fetch ID param
+	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
+	if taskID == -1 {
+		response.Error(c, errorset.ErrInvalidPathParameter)
+		return
+	}
+
+	logger.Info("decoded request", slog.Int64(helper.TaskIDKey, taskID))
+
+	// action with db
+	if err := t.db.PurgeTask(c.Request.Context(), userID, taskID); err != nil {
+		handleDeletingTaskError(c, logger, err)
+		return
+	}
+
+	logger.Info("task purged successfully", slog.Int64(helper.TaskIDKey, taskID))
+	response.Ok(c, http.StatusOK, nil)
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"restapi/internal/errorset"
+	"restapi/internal/lib/etag"
 	helper "restapi/internal/lib/helperfunctions"
 	"restapi/internal/lib/sl"
 	"restapi/internal/models/response"
@@ -18,10 +19,17 @@ func (t TaskHandler) UpdateTask(c *gin.Context) {
 	const op = "handlers.task.TaskHandler.UpdateTask"
 	logger := helper.LoadLogger(t.log, c, op)
 
+	// fetch ID from token
+	userID := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userID == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
 	// fetch ID param
 	taskID := helper.GetIDFromParams(c, helper.TaskIDKey)
 	if taskID == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidPathParameter)
 		return
 	}
 
@@ -29,20 +37,32 @@ func (t TaskHandler) UpdateTask(c *gin.Context) {
 	var req request
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error(errorset.ErrBindRequest, sl.Err(err))
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.BindError(c, err)
 		return
 	}
 
 	logger.Info("decoded request", slog.Any(helper.ReqKey, req))
 
+	// check precondition
+	version, err := t.ifMatchVersion(c, userID, taskID)
+	if err != nil {
+		handleUpdatingTaskError(c, logger, err)
+		return
+	}
+
 	// action with db
-	err := t.db.UpdateTaskContent(int64(taskID), req.TaskContent)
+	version, err = t.db.UpdateTaskContent(c.Request.Context(), userID, taskID, req.TaskContent, version)
 	if err != nil {
-		logger.Error("failed to update task", sl.Err(err))
-		response.Error(c, http.StatusInternalServerError, "failed to update task")
+		handleUpdatingTaskError(c, logger, err)
 		return
 	}
 
 	logger.Info("task updated successfully", slog.Int64(helper.TaskIDKey, taskID))
+	c.Header(etag.Header, etag.Format(version))
 	response.Ok(c, http.StatusOK, nil)
 }
+
+func handleUpdatingTaskError(c *gin.Context, log *slog.Logger, err error) {
+	log.Error("failed to update task", sl.Err(err))
+	response.Error(c, err)
+}
@@ -22,29 +22,32 @@ func (u UserHandler) DeleteUser(c *gin.Context) {
 	// fetch ID param
 	userId := helper.FetchIDFromToken(c, helper.UserIDKey)
 	if userId == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidTokenClaims)
 		return
 	}
 
 	logger.Info("decoded request", slog.Any(helper.UserIDKey, userId))
 
 	// action with db
-	err := u.db.DeleteUser(userId)
+	err := u.db.DeleteUser(c.Request.Context(), userId)
 	if err != nil {
 		handleDeletingUserError(c, logger, err)
 		return
 	}
 
+	// tokens of a deleted user are rejected once its cached state is gone
+	u.checker.ForgetUser(userId)
+
 	response.Ok(c, http.StatusOK, nil)
 }
 
 func handleDeletingUserError(c *gin.Context, log *slog.Logger, err error) {
 	if errors.Is(err, errorset.ErrUserNotFound) {
 		log.Error(errorset.ErrUserNotFound.Error(), sl.Err(err))
-		response.Error(c, http.StatusNotFound, errorset.ErrUserNotFound.Error())
+		response.Error(c, errorset.ErrUserNotFound)
 		return
 	}
 
 	log.Error("failed to delete user", sl.Err(err))
-	response.Error(c, http.StatusInternalServerError, "failed to delete user")
+	response.Error(c, err)
 }
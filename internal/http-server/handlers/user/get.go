@@ -23,14 +23,14 @@ func (u UserHandler) GetUser(c *gin.Context) {
 	// fetch ID param
 	userId := helper.FetchIDFromToken(c, helper.UserIDKey)
 	if userId == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidTokenClaims)
 		return
 	}
 
 	logger.Info("decoded request", slog.Any(helper.UserIDKey, userId))
 
 	// action with db
-	userObject, err := u.db.GetUserByID(int64(userId))
+	userObject, err := u.db.GetUserByID(c.Request.Context(), int64(userId))
 	if err != nil {
 		handleGettingUserError(c, logger, err)
 		return
@@ -45,10 +45,10 @@ func (u UserHandler) GetUser(c *gin.Context) {
 func handleGettingUserError(c *gin.Context, log *slog.Logger, err error) {
 	if errors.Is(err, errorset.ErrUserNotFound) {
 		log.Error(err.Error(), sl.Err(err))
-		response.Error(c, http.StatusNotFound, err.Error())
+		response.Error(c, err)
 		return
 	}
 
 	log.Error("failed to get user", sl.Err(err))
-	response.Error(c, http.StatusInternalServerError, "failed to get user")
+	response.Error(c, err)
 }
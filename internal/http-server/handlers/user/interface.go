@@ -2,6 +2,8 @@ package user
 
 import (
 	"log/slog"
+	"restapi/internal/lib/metrics"
+	"restapi/internal/lib/revocation"
 	"restapi/internal/storage"
 
 	"github.com/gin-gonic/gin"
@@ -10,19 +12,24 @@ import (
 type UserHandlers interface {
 	DeleteUser(c *gin.Context)
 	GetUser(c *gin.Context)
+	PatchUser(c *gin.Context)
 	UpdateUserPassword(c *gin.Context)
 	SaveUser(c *gin.Context)
 }
 
 type UserHandler struct {
-	log *slog.Logger
-	db  storage.Storage
+	log     *slog.Logger
+	db      storage.Storage
+	checker *revocation.Checker
+	metrics *metrics.Metrics
 }
 
-func NewUserHandler(log *slog.Logger, db storage.Storage) UserHandlers {
+func NewUserHandler(log *slog.Logger, db storage.Storage, checker *revocation.Checker, metrics *metrics.Metrics) UserHandlers {
 	return UserHandler{
-		log: log,
-		db:  db,
+		log:     log,
+		db:      db,
+		checker: checker,
+		metrics: metrics,
 	}
 }
 
@@ -0,0 +1,118 @@
+package user
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/patch"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/data"
+	"restapi/internal/models/response"
+	"restapi/internal/models/user"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patchDocument is a user as a patch sees it. Only the fields with binding rules
+// may change, the others are read-only.
+type patchDocument struct {
+	UserID     int64      `json:"userId"`
+	UserName   string     `json:"username" binding:"required,min=3,max=50"`
+	Role       string     `json:"role"`
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// PatchUser implements UserHandlers.
+func (u UserHandler) PatchUser(c *gin.Context) {
+	// load logger with necessary data
+	const op = "handlers.user.patch.PatchUserHandler"
+	logger := helper.LoadLogger(u.log, c, op)
+
+	// fetch ID from token
+	userId := helper.FetchIDFromToken(c, helper.UserIDKey)
+	if userId == -1 {
+		response.Error(c, errorset.ErrInvalidTokenClaims)
+		return
+	}
+
+	// read patch
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, patch.MaxSize))
+	if err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+
+	logger.Info("decoded request", slog.String("content_type", c.ContentType()), slog.Int("size", len(body)))
+
+	// the patch applies to the user as GET /user returns it
+	current, err := u.db.GetUserByID(c.Request.Context(), userId)
+	if err != nil {
+		handlePatchingUserError(c, logger, err)
+		return
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		handlePatchingUserError(c, logger, err)
+		return
+	}
+
+	patched, err := patch.Apply(c.ContentType(), doc, body)
+	if err != nil {
+		handlePatchingUserError(c, logger, err)
+		return
+	}
+
+	// validate the patched user
+	var result patchDocument
+	if err := patch.Bind(patched, &result); err != nil {
+		logger.Error(errorset.ErrBindRequest, sl.Err(err))
+		response.BindError(c, err)
+		return
+	}
+	if readOnly := readOnlyChanges(current, result); len(readOnly) > 0 {
+		logger.Error(errorset.ErrBindRequest, sl.Err(readOnly))
+		response.BindError(c, readOnly)
+		return
+	}
+
+	// action with db: only a changed username is written
+	if result.UserName != current.UserName {
+		if err := u.db.UpdateUsername(c.Request.Context(), userId, result.UserName); err != nil {
+			handlePatchingUserError(c, logger, err)
+			return
+		}
+		current.UserName = result.UserName
+	}
+
+	var data data.Data = data.NewData()
+	data[helper.UserKey] = current
+
+	logger.Info("user patched successfully", slog.Int64(helper.UserIDKey, userId))
+	response.Ok(c, http.StatusOK, data)
+}
+
+// readOnlyChanges lists the read-only fields the patch changed
+func readOnlyChanges(current *user.User, result patchDocument) response.FieldErrors {
+	disabledChanged := (result.DisabledAt == nil) != (current.DisabledAt == nil) ||
+		(result.DisabledAt != nil && !result.DisabledAt.Equal(*current.DisabledAt))
+
+	return response.ReadOnlyErrors(
+		response.ReadOnlyField{Name: "userId", Changed: result.UserID != current.UserID},
+		response.ReadOnlyField{Name: "role", Changed: result.Role != current.Role},
+		response.ReadOnlyField{Name: "disabledAt", Changed: disabledChanged},
+		response.ReadOnlyField{Name: "createdAt", Changed: !result.CreatedAt.Equal(current.CreatedAt)},
+	)
+}
+
+func handlePatchingUserError(c *gin.Context, log *slog.Logger, err error) {
+	log.Error("failed to patch user", sl.Err(err))
+	response.Error(c, err)
+}
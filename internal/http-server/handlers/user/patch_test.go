@@ -0,0 +1,152 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/patch"
+	"restapi/internal/models/memory"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+func TestPatchUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		wantStatus   int
+		wantCode     string
+		wantField    string
+		wantUsername string
+	}{
+		{
+			name:         "merge patch renames",
+			contentType:  patch.MergePatchType,
+			body:         `{"username":"alicia"}`,
+			wantStatus:   http.StatusOK,
+			wantUsername: "alicia",
+		},
+		{
+			name:         "json patch renames",
+			contentType:  patch.JSONPatchType,
+			body:         `[{"op":"test","path":"/username","value":"alice"},{"op":"replace","path":"/username","value":"alicia"}]`,
+			wantStatus:   http.StatusOK,
+			wantUsername: "alicia",
+		},
+		{
+			name:         "unchanged username",
+			contentType:  patch.MergePatchType,
+			body:         `{}`,
+			wantStatus:   http.StatusOK,
+			wantUsername: "alice",
+		},
+		{
+			name:         "taken username",
+			contentType:  patch.MergePatchType,
+			body:         `{"username":"bob"}`,
+			wantStatus:   http.StatusConflict,
+			wantCode:     "duplicate_user",
+			wantUsername: "alice",
+		},
+		{
+			name:         "too short username",
+			contentType:  patch.MergePatchType,
+			body:         `{"username":"al"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantCode:     "validation_failed",
+			wantField:    "username",
+			wantUsername: "alice",
+		},
+		{
+			name:         "read-only field",
+			contentType:  patch.MergePatchType,
+			body:         `{"username":"alicia","role":"admin"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantCode:     "validation_failed",
+			wantField:    "role",
+			wantUsername: "alice",
+		},
+		{
+			name:         "unknown field",
+			contentType:  patch.MergePatchType,
+			body:         `{"password":"Password123!"}`,
+			wantStatus:   http.StatusBadRequest,
+			wantCode:     "validation_failed",
+			wantField:    "password",
+			wantUsername: "alice",
+		},
+		{
+			name:         "plain json",
+			contentType:  "application/json",
+			body:         `{"username":"alicia"}`,
+			wantStatus:   http.StatusUnsupportedMediaType,
+			wantCode:     "unsupported_media_type",
+			wantUsername: "alice",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := memory.NewMemory()
+			userID, err := db.SaveUser(ctx, "alice", "Password123!")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.SaveUser(ctx, "bob", "Password123!"); err != nil {
+				t.Fatal(err)
+			}
+
+			handler := NewUserHandler(slog.New(slog.NewTextHandler(io.Discard, nil)), db, nil, nil)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set(helper.ClaimsKey, jwt.MapClaims{helper.UserIDKey: float64(userID)})
+			})
+			router.PATCH("/user", handler.PatchUser)
+
+			req := httptest.NewRequest(http.MethodPatch, "/user", strings.NewReader(tc.body))
+			req.Header.Set("Content-Type", tc.contentType)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tc.wantStatus, rec.Body.String())
+			}
+
+			stored, err := db.GetUserByID(ctx, userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.UserName != tc.wantUsername {
+				t.Errorf("stored username %q, want %q", stored.UserName, tc.wantUsername)
+			}
+
+			if tc.wantStatus == http.StatusOK {
+				return
+			}
+
+			var problem response.Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if problem.Code != tc.wantCode {
+				t.Errorf("code %q, want %q", problem.Code, tc.wantCode)
+			}
+			if tc.wantField != "" && (len(problem.Errors) != 1 || problem.Errors[0].Field != tc.wantField) {
+				t.Errorf("field errors %+v, want one for %s", problem.Errors, tc.wantField)
+			}
+		})
+	}
+}
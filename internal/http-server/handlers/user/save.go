@@ -1,6 +1,7 @@
 package user
 
 import (
+	"errors"
 	"log/slog"
 	"net/http"
 
@@ -25,7 +26,7 @@ func (u UserHandler) SaveUser(c *gin.Context) {
 	var req saveRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error(errorset.ErrBindRequest, sl.Err(err))
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.BindError(c, err)
 		return
 	}
 
@@ -37,12 +38,14 @@ func (u UserHandler) SaveUser(c *gin.Context) {
 	}
 
 	// action with db
-	userId, err := u.db.SaveUser(req.Username, req.Password)
+	userId, err := u.db.SaveUser(c.Request.Context(), req.Username, req.Password)
 	if err != nil || userId == 0 {
 		handleSavingUserError(c, logger, err, userId)
 		return
 	}
 
+	u.metrics.UserRegistered()
+
 	var data data.Data = data.NewData()
 	data[helper.UserIDKey] = userId
 
@@ -53,20 +56,20 @@ func (u UserHandler) SaveUser(c *gin.Context) {
 func validatingRequest(c *gin.Context, log *slog.Logger, req saveRequest, us storage.Storage) error {
 	if !password.IsValidPassword(req.Password) {
 		log.Error(errorset.ErrInvalidPassword.Error())
-		response.Error(c, http.StatusBadRequest, errorset.ErrInvalidPassword.Error())
+		response.Error(c, errorset.ErrInvalidPassword)
 		return errorset.ErrValidation
 	}
 
-	userexists, err := us.UsernameExists(req.Username)
+	userexists, err := us.UsernameExists(c.Request.Context(), req.Username)
 	if err != nil {
 		log.Error("failed to check if username exists", sl.Err(err))
-		response.Error(c, http.StatusInternalServerError, "failed to check if username exists")
+		response.Error(c, err)
 		return errorset.ErrValidation
 	}
 
 	if userexists {
 		log.Warn("username already exists")
-		response.Error(c, http.StatusConflict, "username already exists")
+		response.Error(c, errorset.ErrDuplicateUser)
 		return errorset.ErrValidation
 	}
 
@@ -74,13 +77,20 @@ func validatingRequest(c *gin.Context, log *slog.Logger, req saveRequest, us sto
 }
 
 func handleSavingUserError(c *gin.Context, log *slog.Logger, err error, userId int64) {
+	// lost a race against a concurrent signup with the same username
+	if errors.Is(err, errorset.ErrDuplicateUser) {
+		log.Warn("username already exists")
+		response.Error(c, errorset.ErrDuplicateUser)
+		return
+	}
+
 	if err != nil {
 		log.Error("failed to save user", sl.Err(err))
-		response.Error(c, http.StatusInternalServerError, "failed to save user")
+		response.Error(c, err)
 		return
 	} else if userId == 0 {
 		log.Error("unexpected user ID = 0 after saving user")
-		response.Error(c, http.StatusInternalServerError, "unexpected server error")
+		response.Error(c, errors.New("unexpected user ID = 0 after saving user"))
 		return
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 	"restapi/internal/errorset"
 	helper "restapi/internal/lib/helperfunctions"
 	"restapi/internal/lib/password"
@@ -22,7 +23,7 @@ func (u UserHandler) UpdateUserPassword(c *gin.Context) {
 	// fetch ID param
 	userId := helper.FetchIDFromToken(c, helper.UserIDKey)
 	if userId == -1 {
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.Error(c, errorset.ErrInvalidTokenClaims)
 		return
 	}
 
@@ -30,7 +31,7 @@ func (u UserHandler) UpdateUserPassword(c *gin.Context) {
 	var req updateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Error(errorset.ErrBindRequest, sl.Err(err))
-		response.Error(c, http.StatusBadRequest, errorset.ErrBindRequest)
+		response.BindError(c, err)
 		return
 	}
 
@@ -39,27 +40,30 @@ func (u UserHandler) UpdateUserPassword(c *gin.Context) {
 	// password validation
 	if !password.IsValidPassword(req.Password) {
 		logger.Error(errorset.ErrInvalidPassword.Error())
-		response.Error(c, http.StatusBadRequest, errorset.ErrInvalidPassword.Error())
+		response.Error(c, errorset.ErrInvalidPassword)
 		return
 	}
 
 	// action with db
-	err := u.db.UpdateUserPassword(userId, req.Password)
+	err := u.db.UpdateUserPassword(c.Request.Context(), userId, req.Password)
 	if err != nil {
 		handleUpdatingUserError(c, logger, err)
 		return
 	}
 
+	// the password change revoked every token issued so far
+	u.checker.RevokeUser(userId, time.Now())
+
 	response.Ok(c, http.StatusOK, nil)
 }
 
 func handleUpdatingUserError(c *gin.Context, log *slog.Logger, err error) {
 	if errors.Is(err, errorset.ErrUserNotFound) {
 		log.Error(errorset.ErrUserNotFound.Error(), sl.Err(err))
-		response.Error(c, http.StatusNotFound, errorset.ErrUserNotFound.Error())
+		response.Error(c, errorset.ErrUserNotFound)
 		return
 	}
 
 	log.Error("failed to update user password", sl.Err(err))
-	response.Error(c, http.StatusInternalServerError, "failed to update user password")
+	response.Error(c, err)
 }
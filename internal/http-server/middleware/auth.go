@@ -1,10 +1,11 @@
 package middleware
 
 import (
-	"net/http"
+	"fmt"
 	"restapi/internal/errorset"
 	helper "restapi/internal/lib/helperfunctions"
 	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/revocation"
 	"restapi/internal/models/response"
 
 	"github.com/gin-gonic/gin"
@@ -14,22 +15,38 @@ const (
 	AuthorizationHeader = "Authorization"
 )
 
-func JWNAuthMiddleware () gin.HandlerFunc {
+func JWNAuthMiddleware (tokens *jwtutil.Manager, checker *revocation.Checker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		tokenString, err := helper.FetchTokenFromContext(c)
 		if err != nil {
-			response.Error(c, http.StatusUnauthorized, errorset.ErrAuthorizationMissing)
+			response.Error(c, err)
 			c.Abort()
 			return
 		}
 
-		claims, err := jwtutil.ValidateJWT(tokenString)
+		claims, err := tokens.ValidateJWT(tokenString)
 		if err != nil {
-			response.Error(c, http.StatusUnauthorized, err.Error())
+			response.Error(c, fmt.Errorf("%w: %w", errorset.ErrInvalidToken, err))
 			c.Abort()
 			return
 		}
 
+		userID, okUserID := helper.ClaimInt64(claims, jwtutil.UserIDClaim)
+		issuedAt, okIssuedAt := jwtutil.IssuedAt(claims)
+		jti, okJTI := helper.ClaimString(claims, jwtutil.JTIClaim)
+		if !okUserID || !okIssuedAt || !okJTI {
+			response.Error(c, errorset.ErrInvalidTokenClaims)
+			c.Abort()
+			return
+		}
+
+		if err := checker.Check(c.Request.Context(), jti, userID, issuedAt); err != nil {
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		c.Set(helper.ClaimsKey, claims)
 		c.Set("userId", claims["userId"])
 	}
-}
\ No newline at end of file
+}
@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"restapi/internal/config"
+	"restapi/internal/lib/etag"
+	"restapi/internal/lib/requestid"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -10,8 +12,9 @@ import (
 func CorsWithConfig (addresses config.ServiceAddresses) gin.HandlerFunc {
 	var CorsDefaultConfig cors.Config = cors.Config{
 		AllowOrigins: 		addresses.Addresses,
-		AllowMethods: 		[]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:		[]string{"Content-Type", "Authorization"},
+		AllowMethods: 		[]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:		[]string{"Content-Type", "Authorization", requestid.Header, IdempotencyKeyHeader, etag.IfMatchHeader, etag.IfNoneMatchHeader},
+		ExposeHeaders:		[]string{requestid.Header, RateLimitLimitHeader, RateLimitRemainingHeader, RateLimitResetHeader, RetryAfterHeader, IdempotentReplayedHeader, etag.Header},
 		AllowCredentials: 	true,
 	}
 
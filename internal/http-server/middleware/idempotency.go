@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/idempotency"
+	"restapi/internal/models/response"
+	"restapi/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize bounds the body read into memory to fingerprint the request
+	maxIdempotentBodySize = 1 << 20
+)
+
+// Idempotency stores the first response to a request sent with an Idempotency-Key header for ttl
+// and replays it to retries of the same request. Keys are scoped to the user, or to the service on
+// internal routes, so it must run after the auth middleware.
+// A key reused for a different request is answered with 422, a retry while the first request still
+// runs with 409. Server errors and panics are not stored, so the request can be retried with the same key.
+func Idempotency(log *slog.Logger, db storage.Storage, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		scope, ok := idempotencyScope(c)
+		if key == "" || !ok {
+			c.Next()
+			return
+		}
+
+		logger := helper.LoadLogger(log, c, "middleware.Idempotency")
+
+		if len(key) > maxIdempotencyKeyLength {
+			response.Error(c, errorset.ErrInvalidIdempotencyKey)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		if err != nil {
+			response.BindError(c, err)
+			c.Abort()
+			return
+		}
+		// restore the body for the handler
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		record := &idempotency.Record{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: requestHash(c.Request, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+
+		err = db.SaveIdempotencyKey(ctx, record)
+		if errors.Is(err, errorset.ErrIdempotencyKeyExists) {
+			replay(c, logger, db, record)
+			return
+		}
+		if err != nil {
+			logger.Error("failed to save idempotency key", sl.Err(err))
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// a panicking handler leaves no response to store: release the key so the request can be retried
+		defer func() {
+			if p := recover(); p != nil {
+				if err := db.DeleteIdempotencyKey(context.WithoutCancel(ctx), scope, key); err != nil {
+					logger.Error("failed to release idempotency key", sl.Err(err))
+				}
+				panic(p)
+			}
+		}()
+
+		c.Next()
+
+		// the response is stored even if the client is gone, its retry is what needs it
+		ctx = context.WithoutCancel(ctx)
+
+		if status := writer.Status(); status >= http.StatusInternalServerError {
+			err = db.DeleteIdempotencyKey(ctx, scope, key)
+		} else {
+			err = db.CompleteIdempotencyKey(ctx, scope, key, status, writer.body.Bytes())
+		}
+		if err != nil {
+			// retries wait for the key to expire
+			logger.Error("failed to store idempotent response", sl.Err(err))
+		}
+	}
+}
+
+// replay answers a retry with the stored response of the first request
+func replay(c *gin.Context, logger *slog.Logger, db storage.Storage, record *idempotency.Record) {
+	defer c.Abort()
+
+	stored, err := db.GetIdempotencyKey(c.Request.Context(), record.Scope, record.Key)
+	if errors.Is(err, errorset.ErrIdempotencyKeyNotFound) {
+		// released by a failed first request or expired since the save, so the client can retry now
+		response.Error(c, errorset.ErrIdempotencyKeyInProgress)
+		return
+	}
+	if err != nil {
+		logger.Error("failed to get idempotency key", sl.Err(err))
+		response.Error(c, err)
+		return
+	}
+
+	switch {
+	case stored.RequestHash != record.RequestHash:
+		response.Error(c, errorset.ErrIdempotencyKeyMismatch)
+	case !stored.Done():
+		response.Error(c, errorset.ErrIdempotencyKeyInProgress)
+	default:
+		logger.Info("replaying idempotent response", slog.Int("status", stored.StatusCode))
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(stored.StatusCode, replayContentType(stored.StatusCode), stored.Body)
+	}
+}
+
+// replayContentType is the content type of a stored response, which is a problem if it failed
+func replayContentType(status int) string {
+	if status >= http.StatusBadRequest {
+		return response.ProblemContentType
+	}
+
+	return "application/json; charset=utf-8"
+}
+
+// idempotencyScope names the owner of the keys of the request, the user or the internal service
+func idempotencyScope(c *gin.Context) (string, bool) {
+	if claims, ok := helper.FetchClaimsFromContext(c); ok {
+		if userID, ok := helper.ClaimInt64(claims, jwtutil.UserIDClaim); ok {
+			return "user:" + strconv.FormatInt(userID, 10), true
+		}
+	}
+
+	if service := c.GetString(ServiceKey); service != "" {
+		return "service:" + service, true
+	}
+
+	return "", false
+}
+
+// requestHash fingerprints a request by method, path and body
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/models/idempotency"
+	"restapi/internal/models/memory"
+	"restapi/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+)
+
+// setupIdempotentRouter serves POST /tasks for user 1, answering with the number of tasks created so far
+// or with 500 while failing is set
+func setupIdempotentRouter(db storage.Storage, created *int, failing *bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(helper.ClaimsKey, jwt.MapClaims{helper.UserIDKey: float64(1)})
+	})
+	router.POST("/tasks", Idempotency(slog.New(slog.NewTextHandler(io.Discard, nil)), db, time.Hour), func(c *gin.Context) {
+		if *failing {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "boom"})
+			return
+		}
+
+		*created++
+		c.JSON(http.StatusCreated, gin.H{"taskId": *created})
+	})
+
+	return router
+}
+
+func postTask(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestIdempotency(t *testing.T) {
+	var created int
+	var failing bool
+	router := setupIdempotentRouter(memory.NewMemory(), &created, &failing)
+
+	first := postTask(router, "key-1", `{"content":"buy milk"}`)
+	retry := postTask(router, "key-1", `{"content":"buy milk"}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry answered %d %s, want the first response %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if created != 1 || retry.Header().Get(IdempotentReplayedHeader) != "true" || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("created %d tasks, replayed header %q, want 1 task and only the retry marked as replayed", created, retry.Header().Get(IdempotentReplayedHeader))
+	}
+
+	if rec := postTask(router, "key-1", `{"content":"buy bread"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("same key with another body answered %d, want 422", rec.Code)
+	}
+
+	postTask(router, "", `{"content":"buy milk"}`)
+	postTask(router, "", `{"content":"buy milk"}`)
+	if created != 3 {
+		t.Fatalf("created %d tasks, want requests without a key to run every time", created)
+	}
+
+	if rec := postTask(router, strings.Repeat("k", maxIdempotencyKeyLength+1), `{}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("overlong key answered %d, want 400", rec.Code)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	var created int
+	failing := true
+	router := setupIdempotentRouter(memory.NewMemory(), &created, &failing)
+
+	if rec := postTask(router, "key-1", `{}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("failing request answered %d", rec.Code)
+	}
+
+	failing = false
+	if rec := postTask(router, "key-1", `{}`); rec.Code != http.StatusCreated || created != 1 {
+		t.Fatalf("retry after a server error answered %d with %d tasks created, want it run again", rec.Code, created)
+	}
+}
+
+func TestIdempotencyReleasesKeyOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var created int
+	panicking := true
+
+	router := gin.New()
+	router.Use(gin.RecoveryWithWriter(io.Discard), func(c *gin.Context) {
+		c.Set(helper.ClaimsKey, jwt.MapClaims{helper.UserIDKey: float64(1)})
+	})
+	router.POST("/tasks", Idempotency(slog.New(slog.NewTextHandler(io.Discard, nil)), memory.NewMemory(), time.Hour), func(c *gin.Context) {
+		if panicking {
+			panic("boom")
+		}
+
+		created++
+		c.JSON(http.StatusCreated, gin.H{"taskId": created})
+	})
+
+	if rec := postTask(router, "key-1", `{}`); rec.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request answered %d, want 500", rec.Code)
+	}
+
+	panicking = false
+	if rec := postTask(router, "key-1", `{}`); rec.Code != http.StatusCreated || created != 1 {
+		t.Fatalf("retry after a panic answered %d with %d tasks created, want it run again", rec.Code, created)
+	}
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	var created int
+	var failing bool
+	db := memory.NewMemory()
+	router := setupIdempotentRouter(db, &created, &failing)
+
+	// the first request with the key is still running
+	req := httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(`{}`))
+	inProgress := &idempotency.Record{Scope: "user:1", Key: "key-1", RequestHash: requestHash(req, []byte(`{}`)), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.SaveIdempotencyKey(context.Background(), inProgress); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec := postTask(router, "key-1", `{}`); rec.Code != http.StatusConflict || created != 0 {
+		t.Fatalf("retry during the first request answered %d, created %d tasks, want 409 and nothing created", rec.Code, created)
+	}
+
+	if rec := postTask(router, "key-2", `{}`); rec.Code != http.StatusCreated {
+		t.Fatalf("another key answered %d", rec.Code)
+	}
+}
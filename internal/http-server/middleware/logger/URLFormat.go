@@ -1,13 +1,15 @@
 package logger
 
 import (
+	"strings"
+
 	"github.com/gin-gonic/gin"
 )
 
 // URLFormat is a middleware that ensures the URL is properly formatted
 func URLFormat() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if ext := c.Request.URL.Path[len(c.Request.URL.Path)-5:]; ext == ".json" {
+		if strings.HasSuffix(c.Request.URL.Path, ".json") {
 			c.Header("Content-Type", "application/json")
 		}
 		c.Next()
@@ -4,6 +4,9 @@ import (
 	"time"
 	"log/slog"
 
+	"restapi/internal/lib/requestid"
+	"restapi/internal/lib/tracing"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -49,7 +52,7 @@ func New(log *slog.Logger) gin.HandlerFunc {
 			slog.String("path", c.Request.URL.Path),
 			slog.String("remote_addr", c.ClientIP()),
 			slog.String("user_agent", c.Request.UserAgent()),
-		)
+		).With(requestid.LogAttrs(c.Request.Context())...).With(tracing.LogAttrs(c.Request.Context())...)
 
 		t1 := time.Now()
 		c.Next()
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"restapi/internal/lib/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute labels requests no route matched, so unknown paths don't create new series
+const unmatchedRoute = "unmatched"
+
+// Metrics records the count and latency of requests by route template
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		done := m.RequestStarted()
+
+		// Recovery runs outside this middleware and answers a panicking handler with 500:
+		// count it as such and leave the panic to Recovery
+		defer func() {
+			p := recover()
+
+			status := c.Writer.Status()
+			if p != nil {
+				status = http.StatusInternalServerError
+			}
+
+			route := c.FullPath()
+			if route == "" {
+				route = unmatchedRoute
+			}
+
+			done(route, c.Request.Method, status)
+
+			if p != nil {
+				panic(p)
+			}
+		}()
+
+		c.Next()
+	}
+}
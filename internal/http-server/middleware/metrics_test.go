@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"restapi/internal/lib/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+type noPool struct{}
+
+func (noPool) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
+
+func TestMetricsCountsPanickingHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	m := metrics.New(noPool{})
+	router := gin.New()
+	router.Use(gin.Recovery(), Metrics(m))
+	router.GET("/panic", func(c *gin.Context) {
+		panic("handler failed")
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking handler answered %d, want 500", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+
+	if !strings.Contains(body, `restapi_http_requests_total{method="GET",route="/panic",status="500"} 1`) {
+		t.Error("panicking request is not counted as a 500")
+	}
+	if !strings.Contains(body, "restapi_http_requests_in_flight 0") {
+		t.Error("panicking request is still counted in flight")
+	}
+}
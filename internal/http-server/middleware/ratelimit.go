@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"restapi/internal/config"
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/ratelimit"
+	"restapi/internal/lib/requestid"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// RateLimit lets every client of the route group through with a token bucket of limit.Burst requests,
+// refilled with limit.Requests every limit.Per. Clients are told apart by the userId claim, then by
+// the service ServiceAuth authenticated and last by IP, so it must run after the auth middleware of
+// the route. Unverified credentials are never used, a client could pick a new bucket per request.
+// If the store fails, the request is let through rather than failing the API with it.
+func RateLimit(log *slog.Logger, store ratelimit.Store, group string, limit config.RateLimit) gin.HandlerFunc {
+	if limit.Requests <= 0 || limit.Per <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Requests
+	}
+	rate := float64(limit.Requests) / limit.Per.Seconds()
+
+	return func(c *gin.Context) {
+		result, err := store.Take(c.Request.Context(), group+":"+clientKey(c), rate, burst)
+		if err != nil {
+			log.With(requestid.LogAttrs(c.Request.Context())...).Error("failed to take a rate limit token, letting the request through",
+				slog.String("middleware", "RateLimit"), slog.String("group", group), sl.Err(err))
+			c.Next()
+			return
+		}
+
+		c.Header(RateLimitLimitHeader, strconv.Itoa(burst))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+		c.Header(RateLimitResetHeader, ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			c.Header(RetryAfterHeader, ceilSeconds(result.RetryAfter))
+			response.Error(c, errorset.ErrRateLimited)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientKey names the bucket of the caller from the identities authenticated before
+func clientKey(c *gin.Context) string {
+	if claims, ok := helper.FetchClaimsFromContext(c); ok {
+		if userID, ok := helper.ClaimInt64(claims, jwtutil.UserIDClaim); ok {
+			return "user:" + strconv.FormatInt(userID, 10)
+		}
+	}
+
+	if service := c.GetString(ServiceKey); service != "" {
+		return "service:" + service
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds formats d in whole seconds, rounded up so clients don't retry too early
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,154 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"restapi/internal/config"
+	"restapi/internal/lib/ratelimit"
+	"restapi/internal/lib/servicesig"
+
+	"github.com/gin-gonic/gin"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, float64, int) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("store is down")
+}
+
+// serviceKeys stands in for ServiceAuth: only these keys authenticate a service
+var serviceKeys = map[string]string{"signup-service-key": "signup"}
+
+func setupRateLimitRouter(store ratelimit.Store, limit config.RateLimit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	created := func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	}
+
+	// like the app without http_server.trusted_proxies
+	router := gin.New()
+	router.SetTrustedProxies(nil)
+	router.POST("/user", func(c *gin.Context) {
+		if service, ok := serviceKeys[c.GetHeader(servicesig.APIKeyHeader)]; ok {
+			c.Set(ServiceKey, service)
+		}
+	}, RateLimit(log, store, "signup", limit), created)
+	router.POST("/auth/login", RateLimit(log, store, "auth", limit), created)
+
+	return router
+}
+
+func postUser(router *gin.Engine, apiKey string) *httptest.ResponseRecorder {
+	return post(router, "/user", apiKey)
+}
+
+func post(router *gin.Engine, path, apiKey string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, nil)
+	if apiKey != "" {
+		req.Header.Set(servicesig.APIKeyHeader, apiKey)
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestRateLimit(t *testing.T) {
+	router := setupRateLimitRouter(ratelimit.NewMemoryStore(), config.RateLimit{Requests: 2, Per: time.Minute})
+
+	for i := 1; i >= 0; i-- {
+		rec := postUser(router, "")
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("request within the limit answered %d", rec.Code)
+		}
+		if rec.Header().Get(RateLimitLimitHeader) != "2" || rec.Header().Get(RateLimitRemainingHeader) != strconv.Itoa(i) {
+			t.Fatalf("rate limit headers %v, want limit 2 and %d remaining", rec.Header(), i)
+		}
+	}
+
+	rec := postUser(router, "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request over the limit answered %d, want 429", rec.Code)
+	}
+	if rec.Header().Get(RetryAfterHeader) != "30" || rec.Header().Get(RateLimitResetHeader) != "60" {
+		t.Fatalf("headers %v, want Retry-After 30 and RateLimit-Reset 60", rec.Header())
+	}
+
+	// an unknown API key doesn't get a bucket of its own
+	if rec := postUser(router, "made-up-key"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("request with an unknown API key answered %d, want 429", rec.Code)
+	}
+
+	// an authenticated service has its own bucket
+	if rec := postUser(router, "signup-service-key"); rec.Code != http.StatusCreated {
+		t.Fatalf("request of an authenticated service answered %d", rec.Code)
+	}
+}
+
+func TestRateLimitIgnoresRotatingAPIKeys(t *testing.T) {
+	router := setupRateLimitRouter(ratelimit.NewMemoryStore(), config.RateLimit{Requests: 2, Per: time.Minute})
+
+	for i := 0; i < 2; i++ {
+		if rec := post(router, "/auth/login", "key-"+strconv.Itoa(i)); rec.Code != http.StatusCreated {
+			t.Fatalf("login within the limit answered %d", rec.Code)
+		}
+	}
+
+	if rec := post(router, "/auth/login", "key-2"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("login with a new API key over the limit answered %d, want 429", rec.Code)
+	}
+}
+
+func TestRateLimitLetsThroughWhenStoreFails(t *testing.T) {
+	router := setupRateLimitRouter(failingStore{}, config.RateLimit{Requests: 1, Per: time.Minute})
+
+	if rec := postUser(router, ""); rec.Code != http.StatusCreated {
+		t.Fatalf("request with a failing store answered %d, want it let through", rec.Code)
+	}
+}
+
+func TestRateLimitIgnoresSpoofedForwardedFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		trustedProxies []string
+		wantStatus     int
+	}{
+		{"untrusted peer", nil, http.StatusTooManyRequests},
+		{"trusted proxy", []string{"192.0.2.0/24"}, http.StatusCreated},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := setupRateLimitRouter(ratelimit.NewMemoryStore(), config.RateLimit{Requests: 2, Per: time.Minute})
+			if err := router.SetTrustedProxies(tc.trustedProxies); err != nil {
+				t.Fatal(err)
+			}
+
+			// every login comes from the same peer with a different X-Forwarded-For
+			var rec *httptest.ResponseRecorder
+			for i := 0; i < 3; i++ {
+				req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+				req.RemoteAddr = "192.0.2.1:40000"
+				req.Header.Set("X-Forwarded-For", "203.0.113."+strconv.Itoa(i))
+
+				rec = httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+			}
+
+			if rec.Code != tc.wantStatus {
+				t.Errorf("third login answered %d, want %d", rec.Code, tc.wantStatus)
+			}
+		})
+	}
+}
@@ -1,24 +1,24 @@
 package middleware
 
 import (
+	"restapi/internal/lib/requestid"
+
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 )
 
-// RequestIDMiddleware adds a request ID to the context if missing
+// RequestIDMiddleware attaches the X-Request-ID of the caller to the request context and echoes it back.
+// A missing or malformed ID is replaced with a new one, so logs never carry untrusted content.
+// It must run before the logger, so access logs carry the ID as well.
 func RequestIDMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID") // Fetch from request header
-		if requestID == "" {
-			requestID = uuid.New().String() // Generate new if missing
+		requestID := c.GetHeader(requestid.Header)
+		if !requestid.Valid(requestID) {
+			requestID = requestid.New()
 		}
 
-		// Store in Gin context
-		c.Set("RequestID", requestID)
-
-		// Set response header for tracking
-		c.Writer.Header().Set("X-Request-ID", requestID)
+		c.Request = c.Request.WithContext(requestid.WithID(c.Request.Context(), requestID))
+		c.Writer.Header().Set(requestid.Header, requestID)
 
-		c.Next() // Continue request
+		c.Next()
 	}
 }
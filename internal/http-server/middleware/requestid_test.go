@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/requestid"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestIDMiddleware())
+
+	var seen string
+	router.GET("/fail", func(c *gin.Context) {
+		seen = requestid.FromContext(c.Request.Context())
+		response.Error(c, errorset.ErrValidation)
+	})
+
+	for header, keep := range map[string]bool{
+		"":                 false,
+		"client-id-1":      true,
+		"evil\" id=forged": false,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+		if header != "" {
+			req.Header.Set(requestid.Header, header)
+		}
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var body response.Problem
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+
+		echoed := rec.Header().Get(requestid.Header)
+		if !requestid.Valid(echoed) || echoed != seen || body.RequestID != seen {
+			t.Fatalf("header %q: echoed %q, context %q, body %q, want the same valid ID", header, echoed, seen, body.RequestID)
+		}
+		if keep != (seen == header) {
+			t.Fatalf("header %q: request ID %q, kept = %v, want %v", header, seen, seen == header, keep)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"slices"
+
+	"restapi/internal/errorset"
+	helper "restapi/internal/lib/helperfunctions"
+	"restapi/internal/lib/jwtutil"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole lets the request through only if the role claim of the token
+// validated by JWNAuthMiddleware is one of roles
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, _ := helper.FetchClaimsFromContext(c)
+		role, ok := helper.ClaimString(claims, jwtutil.RoleClaim)
+
+		if !ok || !slices.Contains(roles, role) {
+			response.Error(c, errorset.ErrForbidden)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
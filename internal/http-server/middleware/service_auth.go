@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/requestid"
+	"restapi/internal/lib/servicesig"
+	"restapi/internal/lib/sl"
+	"restapi/internal/models/response"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ServiceKey = "service"
+
+	// maxSignedBodySize bounds the body read into memory to check its signature
+	maxSignedBodySize = 1 << 20
+)
+
+// ServiceAuth lets through requests of internal services that present a
+// configured API key in X-API-Key or sign the request with a configured HMAC key
+func ServiceAuth(log *slog.Logger, verifier *servicesig.Verifier) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := log.With(slog.String("middleware", "ServiceAuth")).With(requestid.LogAttrs(c.Request.Context())...)
+
+		var service string
+		var err error
+
+		if apiKey := c.GetHeader(servicesig.APIKeyHeader); apiKey != "" {
+			service, err = verifier.VerifyAPIKey(apiKey)
+		} else {
+			var body []byte
+			body, err = io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxSignedBodySize))
+			if err == nil {
+				// restore the body for the handler
+				c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+				service, err = verifier.VerifySignature(
+					c.Request.Context(),
+					c.GetHeader(servicesig.KeyIDHeader),
+					c.GetHeader(servicesig.SignatureHeader),
+					servicesig.SignedRequest{
+						Method:    c.Request.Method,
+						URI:       c.Request.URL.RequestURI(),
+						Timestamp: c.GetHeader(servicesig.TimestampHeader),
+						Nonce:     c.GetHeader(servicesig.NonceHeader),
+						Body:      body,
+					},
+				)
+			}
+		}
+
+		if errors.Is(err, servicesig.ErrNonceStore) {
+			// without the nonce store a replay can't be told apart, so the request is refused
+			logger.Error("failed to check the request nonce", sl.Err(err))
+			response.Error(c, err)
+			c.Abort()
+			return
+		}
+
+		if err != nil {
+			logger.Warn("service authentication failed", sl.Err(err), slog.String("remote_addr", c.ClientIP()))
+			response.Error(c, errorset.ErrServiceUnauthorized)
+			c.Abort()
+			return
+		}
+
+		logger.Info("service authenticated", slog.String(ServiceKey, service))
+		c.Set(ServiceKey, service)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package etag
+
+import (
+	"strconv"
+	"strings"
+)
+
+// headers of conditional requests, see RFC 9110 section 13
+const (
+	Header            = "ETag"
+	IfMatchHeader     = "If-Match"
+	IfNoneMatchHeader = "If-None-Match"
+)
+
+// weakPrefix marks a weak entity tag, which If-Match never matches
+const weakPrefix = "W/"
+
+// Format returns the strong entity tag of a resource version
+func Format(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// Precondition is a parsed If-Match header
+type Precondition struct {
+	Any      bool    // the header is "*", any current version matches
+	Versions []int64 // versions of the strong entity tags of the header
+}
+
+// ParseIfMatch parses an If-Match header. Weak and malformed entity tags are left out,
+// they can't match, so a header with only those leaves both fields empty.
+func ParseIfMatch(header string) Precondition {
+	if strings.TrimSpace(header) == "*" {
+		return Precondition{Any: true}
+	}
+
+	var precondition Precondition
+	for _, tag := range splitTags(header) {
+		if strings.HasPrefix(tag, weakPrefix) {
+			continue
+		}
+		if version, ok := parse(tag); ok {
+			precondition.Versions = append(precondition.Versions, version)
+		}
+	}
+
+	return precondition
+}
+
+// Matches reports whether the current version satisfies the precondition
+func (p Precondition) Matches(version int64) bool {
+	if p.Any {
+		return true
+	}
+
+	for _, candidate := range p.Versions {
+		if candidate == version {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NoneMatch reports whether an If-None-Match header lets a read of the version through.
+// It compares weakly, so W/"3" stops version 3 as well.
+func NoneMatch(header string, version int64) bool {
+	if strings.TrimSpace(header) == "*" {
+		return false
+	}
+
+	for _, tag := range splitTags(header) {
+		if candidate, ok := parse(strings.TrimPrefix(tag, weakPrefix)); ok && candidate == version {
+			return false
+		}
+	}
+
+	return true
+}
+
+func splitTags(header string) []string {
+	var tags []string
+	for _, tag := range strings.Split(header, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+// parse reads the version of a quoted entity tag
+func parse(tag string) (int64, bool) {
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(tag[1:len(tag)-1], 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,56 @@
+package etag
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	tests := []struct {
+		header       string
+		wantAny      bool
+		wantVersions []int64
+	}{
+		{header: "*", wantAny: true},
+		{header: `"3"`, wantVersions: []int64{3}},
+		{header: ` "3", "5" `, wantVersions: []int64{3, 5}},
+		{header: `W/"3"`},
+		{header: `"abc", 3, "0"`},
+		{header: `W/"3", "4"`, wantVersions: []int64{4}},
+	}
+
+	for _, tc := range tests {
+		got := ParseIfMatch(tc.header)
+		if got.Any != tc.wantAny || !slices.Equal(got.Versions, tc.wantVersions) {
+			t.Errorf("ParseIfMatch(%q) = %+v, want any %v, versions %v", tc.header, got, tc.wantAny, tc.wantVersions)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	if !ParseIfMatch("*").Matches(7) {
+		t.Error("* does not match version 7")
+	}
+	if !ParseIfMatch(`"6", "7"`).Matches(7) {
+		t.Error(`"6", "7" does not match version 7`)
+	}
+	if ParseIfMatch(`W/"7"`).Matches(7) {
+		t.Error(`weak W/"7" matches version 7`)
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	for header, want := range map[string]bool{
+		"":          true,
+		"*":         false,
+		Format(4):   false,
+		`W/"4"`:     false,
+		`"1", "4"`:  false,
+		`"5"`:       true,
+		`"garbage"`: true,
+	} {
+		if got := NoneMatch(header, 4); got != want {
+			t.Errorf("NoneMatch(%q, 4) = %v, want %v", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"restapi/internal/lib/migrator"
+)
+
+// status of a check and of a report
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check probes one dependency; a nil error means it is up
+type Check struct {
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check; it is up if every check is up
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Failed returns the names of the checks that are down
+func (r Report) Failed() []string {
+	var failed []string
+	for _, result := range r.Checks {
+		if result.Status != StatusUp {
+			failed = append(failed, result.Name)
+		}
+	}
+
+	return failed
+}
+
+// Run runs the checks concurrently, each bounded by timeout
+func Run(ctx context.Context, timeout time.Duration, checks []Check) Report {
+	report := Report{Status: StatusUp, Checks: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = run(ctx, timeout, check)
+		}()
+	}
+	wg.Wait()
+
+	if len(report.Failed()) > 0 {
+		report.Status = StatusDown
+	}
+
+	return report
+}
+
+func run(ctx context.Context, timeout time.Duration, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check.Run(ctx)
+	result := Result{
+		Name:      check.Name,
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+// Pinger is the part of the storage the database check uses
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Database checks that the database answers a ping
+func Database(db Pinger) Check {
+	return Check{Name: "database", Run: db.Ping}
+}
+
+// Migrations checks that every migration of this build is applied and none has drifted.
+// A database migrated by a newer build is up, so a rolling deploy doesn't take the old replicas out.
+func Migrations(m *migrator.Migrator) Check {
+	return Check{Name: "migrations", Run: func(ctx context.Context) error {
+		statuses, err := m.Check(ctx)
+		if errors.Is(err, migrator.ErrMissingMigration) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if pending := migrator.Pending(statuses); len(pending) > 0 {
+			return fmt.Errorf("%d pending migrations", len(pending))
+		}
+
+		return nil
+	}}
+}
+
+// StatsSource is the part of the storage the pool check uses
+type StatsSource interface {
+	Stats() sql.DBStats
+}
+
+// Pool checks that the connection pool is not saturated: over about the last window,
+// requests waited for a connection no longer than maxWait on average.
+// The window doesn't move with the checks, so /readyz and /health see the same waits.
+func Pool(db StatsSource, maxWait, window time.Duration) Check {
+	return pool(db, maxWait, window, time.Now)
+}
+
+type poolSample struct {
+	at    time.Time
+	stats sql.DBStats
+}
+
+func pool(db StatsSource, maxWait, window time.Duration, now func() time.Time) Check {
+	var mu sync.Mutex
+	samples := []poolSample{{at: now(), stats: db.Stats()}}
+
+	return Check{Name: "database_pool", Run: func(ctx context.Context) error {
+		stats := db.Stats()
+
+		mu.Lock()
+		at := now()
+		samples = append(samples, poolSample{at: at, stats: stats})
+		// the baseline is the latest sample at least window old, or the oldest there is
+		for len(samples) > 1 && !samples[1].at.After(at.Add(-window)) {
+			samples = samples[1:]
+		}
+		baseline := samples[0].stats
+		mu.Unlock()
+
+		waits := stats.WaitCount - baseline.WaitCount
+		waited := stats.WaitDuration - baseline.WaitDuration
+
+		if waits > 0 && waited/time.Duration(waits) > maxWait {
+			return fmt.Errorf("pool saturated: %d of %d connections in use, %d waits averaging %s",
+				stats.InUse, stats.MaxOpenConnections, waits, waited/time.Duration(waits))
+		}
+
+		return nil
+	}}
+}
@@ -0,0 +1,77 @@
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	checks := []Check{
+		{Name: "fast", Run: func(ctx context.Context) error { return nil }},
+		{Name: "broken", Run: func(ctx context.Context) error { return errors.New("connection refused") }},
+		{Name: "slow", Run: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}},
+	}
+
+	report := Run(context.Background(), 10*time.Millisecond, checks)
+	if report.Status != StatusDown {
+		t.Fatalf("report status = %s, want down", report.Status)
+	}
+	if failed := report.Failed(); !slices.Equal(failed, []string{"broken", "slow"}) {
+		t.Fatalf("Failed = %v, want broken and slow", failed)
+	}
+	if report.Checks[1].Error != "connection refused" {
+		t.Fatalf("broken check error = %q", report.Checks[1].Error)
+	}
+
+	if report := Run(context.Background(), time.Second, checks[:1]); report.Status != StatusUp {
+		t.Fatalf("report of passing checks = %+v, want up", report)
+	}
+}
+
+type stats struct {
+	sql.DBStats
+}
+
+func (s *stats) Stats() sql.DBStats {
+	return s.DBStats
+}
+
+func TestPool(t *testing.T) {
+	db := &stats{}
+	clock := time.Unix(1_000_000, 0)
+	check := pool(db, 100*time.Millisecond, time.Minute, func() time.Time { return clock })
+
+	if err := check.Run(context.Background()); err != nil {
+		t.Fatalf("idle pool: %v", err)
+	}
+
+	clock = clock.Add(10 * time.Second)
+	db.WaitCount, db.WaitDuration = 10, 100*time.Millisecond
+	if err := check.Run(context.Background()); err != nil {
+		t.Fatalf("short waits: %v", err)
+	}
+
+	clock = clock.Add(10 * time.Second)
+	db.WaitCount, db.WaitDuration = 20, 3*time.Second
+	if err := check.Run(context.Background()); err == nil {
+		t.Fatal("long waits did not fail the check")
+	}
+
+	// another probe right after sees the same waits
+	clock = clock.Add(time.Second)
+	if err := check.Run(context.Background()); err == nil {
+		t.Fatal("long waits passed the second probe")
+	}
+
+	clock = clock.Add(time.Minute)
+	if err := check.Run(context.Background()); err != nil {
+		t.Fatalf("no waits within the window: %v", err)
+	}
+}
@@ -1,14 +1,15 @@
 package helper
 
 import (
-	"fmt"
 	"log/slog"
 	"restapi/internal/errorset"
-	"restapi/internal/lib/jwtutil"
+	"restapi/internal/lib/requestid"
+	"restapi/internal/lib/tracing"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
 )
 
 const (
@@ -16,9 +17,26 @@ const (
 	UserIDKey 			= "userId"
 	TaskKey 			= "task"
 	TasksKey 			= "tasks"
+	NextCursorKey 		= "next_cursor"
+	ResultsKey 			= "results"
+	ReadyKey 			= "ready"
+	ChecksKey 			= "checks"
+	StatusKey 			= "status"
+	PriorityKey 		= "priority"
 	UserKey 			= "user"
+	UsersKey 			= "users"
+	RoleKey 			= "role"
+	RolesKey 			= "roles"
+	ClaimsKey 			= "claims"
 	ReqKey 				= "request"
 	UsernameKey 		= "username"
+	JTIKey 				= "jti"
+	AccessTokenKey 		= "accessToken"
+	RefreshTokenKey 	= "refreshToken"
+	FamilyIDKey 		= "familyId"
+	TokenTypeKey 		= "tokenType"
+	ExpiresInKey 		= "expiresIn"
+	BearerTokenType 	= "Bearer"
 	AuthorizationHeader = "Authorization"
 )
 
@@ -44,48 +62,71 @@ func GetIDFromParams(c *gin.Context, idkey string) int64 {
 }
 
 func LoadLogger(log *slog.Logger, c *gin.Context, operation string) *slog.Logger  {
-	requestID, exists := c.Get("X-Request-ID")
-	if !exists {
-		requestID = "unknown"
-	}
+	ctx := c.Request.Context()
 
 	newLogger := log.With(
 		slog.String("op", operation),
-		slog.String("X-Request-ID", requestID.(string)),
-	)
+	).With(requestid.LogAttrs(ctx)...).With(tracing.LogAttrs(ctx)...)
 
 	return newLogger
 }
 
+// FetchIDFromToken reads an ID claim of the token validated by the auth middleware
 func FetchIDFromToken(c *gin.Context, idkey string) (int64) {
-	token, err := FetchTokenFromContext(c)
-	if err != nil {
-		return -1
-	}
-	
-	claims, err := jwtutil.ValidateJWT(token)
-	if err != nil {
+	claims, ok := FetchClaimsFromContext(c)
+	if !ok {
 		return -1
 	}
 
-	idFloat, ok := claims[idkey].(float64)
-	if !ok || idFloat == 0 {
+	id, ok := ClaimInt64(claims, idkey)
+	if !ok || id == 0 {
 		slog.Error("ID not found or invalid in JWT claims", "key", idkey)
 		return -1
 	}
 
-	return int64(idFloat)
+	return id
+}
+
+// FetchClaimsFromContext returns the claims stored by the auth middleware
+func FetchClaimsFromContext(c *gin.Context) (jwt.MapClaims, bool) {
+	value, exists := c.Get(ClaimsKey)
+	if !exists {
+		return nil, false
+	}
+
+	claims, ok := value.(jwt.MapClaims)
+	return claims, ok
+}
+
+// ClaimInt64 reads a numeric claim, which is decoded from JSON as float64
+func ClaimInt64(claims jwt.MapClaims, key string) (int64, bool) {
+	value, ok := claims[key].(float64)
+	if !ok {
+		return 0, false
+	}
+
+	return int64(value), true
+}
+
+// ClaimString reads a string claim
+func ClaimString(claims jwt.MapClaims, key string) (string, bool) {
+	value, ok := claims[key].(string)
+	if !ok || value == "" {
+		return "", false
+	}
+
+	return value, true
 }
 
 func FetchTokenFromContext(c *gin.Context) (string, error) {
 	authHeader := c.GetHeader(AuthorizationHeader)
 	if authHeader == "" {
-		return "", fmt.Errorf(errorset.ErrAuthorizationMissing)
+		return "", errorset.ErrAuthorizationMissing
 	}
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 	if tokenString == authHeader {
-		return "", fmt.Errorf(errorset.ErrAuthorizationMissing)
+		return "", errorset.ErrInvalidAuthorization
 	}
 
 	return tokenString, nil
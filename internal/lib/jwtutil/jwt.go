@@ -2,30 +2,112 @@ package jwtutil
 
 import (
 	"fmt"
-	"log"
-	"os"
+	"time"
+
+	"restapi/internal/config"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 )
 
-var _JWTSecret []byte
+const (
+	UserIDClaim     = "userId"
+	RoleClaim       = "role"
+	ExpiresAtClaim  = "exp"
+	IssuedAtClaim   = "iat"
+	IssuedAtMsClaim = "iatMs" // iat in milliseconds, to tell a token from a revocation in the same second
+	JTIClaim        = "jti"
+
+	keyIDHeader = "kid"
+)
+
+// Manager signs and validates access tokens with the keys of a KeyProvider
+type Manager struct {
+	provider KeyProvider
+}
 
-func init() {
-	key := os.Getenv("JWT_SECRET_KEY")
-	if key == "" {
-		log.Fatalf("NO JWT SECRET KEY PROVIDED")
+// NewManager creates a Manager backed by provider
+func NewManager(provider KeyProvider) *Manager {
+	return &Manager{provider: provider}
+}
+
+// NewManagerFromConfig loads the configured keys into a StaticKeyProvider
+func NewManagerFromConfig(cfg config.JWTConfig) (*Manager, error) {
+	keys, err := LoadKeys(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load jwt keys: %w", err)
 	}
 
-	_JWTSecret = []byte(key)
+	provider, err := NewStaticKeyProvider(keys)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jwt keys: %w", err)
+	}
+
+	return NewManager(provider), nil
 }
 
-func ValidateJWT(tokenString string) (jwt.MapClaims, error) {
+// GenerateJWT signs an access token for the given user and role that expires after ttl
+func (m *Manager) GenerateJWT(userID int64, role string, ttl time.Duration) (string, error) {
+	key, err := m.provider.SigningKey()
+	if err != nil {
+		return "", fmt.Errorf("error getting signing key: %s", err.Error())
+	}
+
+	now := time.Now()
+
+	claims := jwt.MapClaims{
+		UserIDClaim:     userID,
+		RoleClaim:       role,
+		ExpiresAtClaim:  now.Add(ttl).Unix(),
+		IssuedAtClaim:   now.Unix(),
+		IssuedAtMsClaim: now.UnixMilli(),
+		JTIClaim:        uuid.New().String(),
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header[keyIDHeader] = key.ID
+
+	tokenString, err := token.SignedString(key.signKey)
+	if err != nil {
+		return "", fmt.Errorf("error signing token: %s", err.Error())
+	}
+
+	return tokenString, nil
+}
+
+// IssuedAt returns when a token was issued, to the millisecond. Tokens without the iatMs claim
+// count as issued at the end of their iat second, so a revocation in that second still applies.
+func IssuedAt(claims jwt.MapClaims) (time.Time, bool) {
+	if ms, ok := claims[IssuedAtMsClaim].(float64); ok {
+		return time.UnixMilli(int64(ms)), true
+	}
+
+	if s, ok := claims[IssuedAtClaim].(float64); ok {
+		return time.Unix(int64(s), 0).Add(time.Second - time.Millisecond), true
+	}
+
+	return time.Time{}, false
+}
+
+// ValidateJWT checks the signature against the key named by the kid header
+// and returns the claims of a valid token
+func (m *Manager) ValidateJWT(tokenString string) (jwt.MapClaims, error) {
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, ok := token.Header[keyIDHeader].(string)
+		if !ok || kid == "" {
+			return nil, fmt.Errorf("missing key id")
+		}
+
+		key, err := m.provider.VerificationKey(kid)
+		if err != nil {
+			return nil, err
+		}
+
+		if token.Method.Alg() != key.Method.Alg() {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 
-		return _JWTSecret, nil
+		return key.verifyKey, nil
 	})
 
 	if err != nil {
@@ -0,0 +1,241 @@
+package jwtutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"restapi/internal/config"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func newEdKey(t *testing.T, id, status string) *Key {
+	t.Helper()
+
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	key, err := NewKey(id, status, AlgorithmEdDSA, private, nil)
+	if err != nil {
+		t.Fatalf("failed to create key: %v", err)
+	}
+
+	return key
+}
+
+func newRSAKey(t *testing.T, id, status string) *Key {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	key, err := NewKey(id, status, AlgorithmRS256, private, nil)
+	if err != nil {
+		t.Fatalf("failed to create key: %v", err)
+	}
+
+	return key
+}
+
+func newManager(t *testing.T, keys ...*Key) *Manager {
+	t.Helper()
+
+	provider, err := NewStaticKeyProvider(keys)
+	if err != nil {
+		t.Fatalf("failed to create provider: %v", err)
+	}
+
+	return NewManager(provider)
+}
+
+func TestGenerateAndValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		key  *Key
+	}{
+		{name: "EdDSA", key: newEdKey(t, "ed", KeyStatusActive)},
+		{name: "RS256", key: newRSAKey(t, "rsa", KeyStatusActive)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			manager := newManager(t, tc.key)
+
+			tokenString, err := manager.GenerateJWT(42, "user", time.Minute)
+			if err != nil {
+				t.Fatalf("failed to generate token: %v", err)
+			}
+
+			claims, err := manager.ValidateJWT(tokenString)
+			if err != nil {
+				t.Fatalf("failed to validate token: %v", err)
+			}
+
+			if claims[UserIDClaim] != float64(42) {
+				t.Errorf("expected userId 42, got %v", claims[UserIDClaim])
+			}
+			if claims[RoleClaim] != "user" {
+				t.Errorf("expected role user, got %v", claims[RoleClaim])
+			}
+			for _, claim := range []string{ExpiresAtClaim, IssuedAtClaim, IssuedAtMsClaim, JTIClaim} {
+				if _, ok := claims[claim]; !ok {
+					t.Errorf("expected claim %q", claim)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := newEdKey(t, "old", KeyStatusActive)
+	newKey := newRSAKey(t, "new", KeyStatusVerify)
+
+	oldToken, err := newManager(t, oldKey, newKey).GenerateJWT(1, "user", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	// rotate: the new key signs, the old one only verifies
+	oldKey.Status, newKey.Status = KeyStatusVerify, KeyStatusActive
+	rotated := newManager(t, oldKey, newKey)
+
+	if _, err := rotated.ValidateJWT(oldToken); err != nil {
+		t.Errorf("token of verify key should still validate: %v", err)
+	}
+
+	newToken, err := rotated.GenerateJWT(1, "user", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	if _, err := rotated.ValidateJWT(newToken); err != nil {
+		t.Errorf("token of active key should validate: %v", err)
+	}
+
+	// retire the old key
+	oldKey.Status = KeyStatusRetired
+	retired := newManager(t, oldKey, newKey)
+
+	if _, err := retired.ValidateJWT(oldToken); err == nil {
+		t.Error("token of retired key should be rejected")
+	}
+
+	jwks := retired.JWKS()
+	if len(jwks.Keys) != 1 || jwks.Keys[0].KeyID != "new" || jwks.Keys[0].KeyType != "RSA" {
+		t.Errorf("expected only the new RSA key in JWKS, got %+v", jwks.Keys)
+	}
+}
+
+func TestRejectsUnknownKey(t *testing.T) {
+	tokenString, err := newManager(t, newEdKey(t, "a", KeyStatusActive)).GenerateJWT(1, "user", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if _, err := newManager(t, newEdKey(t, "b", KeyStatusActive)).ValidateJWT(tokenString); err == nil {
+		t.Error("token signed with an unknown kid should be rejected")
+	}
+}
+
+func TestNewStaticKeyProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []*Key
+		wantErr bool
+	}{
+		{name: "single active", keys: []*Key{newEdKey(t, "a", KeyStatusActive)}},
+		{name: "no active", keys: []*Key{newEdKey(t, "a", KeyStatusVerify)}, wantErr: true},
+		{name: "two active", keys: []*Key{newEdKey(t, "a", KeyStatusActive), newEdKey(t, "b", KeyStatusActive)}, wantErr: true},
+		{name: "duplicate kid", keys: []*Key{newEdKey(t, "a", KeyStatusActive), newEdKey(t, "a", KeyStatusVerify)}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewStaticKeyProvider(tc.keys)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error: %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadKeys(t *testing.T) {
+	dir := t.TempDir()
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+
+	privatePath := filepath.Join(dir, "private.pem")
+	publicPath := filepath.Join(dir, "public.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer, err := NewManagerFromConfig(config.JWTConfig{Keys: []config.JWTKey{
+		{KID: "k1", Algorithm: AlgorithmEdDSA, PrivateKeyPath: privatePath, Status: KeyStatusActive},
+	}})
+	if err != nil {
+		t.Fatalf("failed to load private key: %v", err)
+	}
+
+	verifier, err := NewManagerFromConfig(config.JWTConfig{Keys: []config.JWTKey{
+		{KID: "k1", Algorithm: AlgorithmEdDSA, PublicKeyPath: publicPath, Status: KeyStatusVerify},
+		{KID: "k2", Algorithm: AlgorithmEdDSA, PrivateKeyPath: privatePath, Status: KeyStatusActive},
+	}})
+	if err != nil {
+		t.Fatalf("failed to load public key: %v", err)
+	}
+
+	tokenString, err := signer.GenerateJWT(7, "user", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	if _, err := verifier.ValidateJWT(tokenString); err != nil {
+		t.Errorf("token should validate against the public key: %v", err)
+	}
+}
+
+func TestIssuedAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		want   time.Time
+		wantOK bool
+	}{
+		{"milliseconds", jwt.MapClaims{IssuedAtClaim: float64(1700000000), IssuedAtMsClaim: float64(1700000000250)}, time.UnixMilli(1700000000250), true},
+		{"seconds only", jwt.MapClaims{IssuedAtClaim: float64(1700000000)}, time.UnixMilli(1700000000999), true},
+		{"missing", jwt.MapClaims{}, time.Time{}, false},
+	}
+
+	for _, tc := range tests {
+		got, ok := IssuedAt(tc.claims)
+		if ok != tc.wantOK || !got.Equal(tc.want) {
+			t.Errorf("%s: IssuedAt = %v, %v, want %v, %v", tc.name, got, ok, tc.want, tc.wantOK)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package jwtutil
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"restapi/internal/config"
+
+	"github.com/golang-jwt/jwt"
+)
+
+const (
+	// KeyStatusActive marks the key new tokens are signed with; exactly one key is active
+	KeyStatusActive = "active"
+	// KeyStatusVerify marks a key that still verifies tokens but no longer signs them
+	KeyStatusVerify = "verify"
+	// KeyStatusRetired marks a key whose tokens are rejected
+	KeyStatusRetired = "retired"
+
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmHS256 = "HS256"
+
+	// legacySecretEnvKey holds the shared HMAC secret used when no keys are configured
+	legacySecretEnvKey = "JWT_SECRET_KEY"
+	legacyKeyID        = "default"
+)
+
+// Key is a signing or verification key identified by its kid
+type Key struct {
+	ID     string
+	Status string
+	Method jwt.SigningMethod
+	// signKey is nil for keys that can only verify
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// CanSign reports whether the key holds private material
+func (k *Key) CanSign() bool {
+	return k.signKey != nil
+}
+
+// KeyProvider supplies the keys used to sign and validate tokens
+type KeyProvider interface {
+	// SigningKey returns the active key
+	SigningKey() (*Key, error)
+	// VerificationKey returns the key with the given kid unless it is retired
+	VerificationKey(kid string) (*Key, error)
+	// Keys returns every known key
+	Keys() []*Key
+}
+
+// StaticKeyProvider serves a fixed set of keys
+type StaticKeyProvider struct {
+	active *Key
+	keys   map[string]*Key
+	order  []*Key
+}
+
+// NewStaticKeyProvider checks that the kids are unique and that exactly one
+// key is active and able to sign
+func NewStaticKeyProvider(keys []*Key) (*StaticKeyProvider, error) {
+	provider := &StaticKeyProvider{keys: make(map[string]*Key, len(keys))}
+
+	for _, key := range keys {
+		if _, exists := provider.keys[key.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.ID)
+		}
+
+		switch key.Status {
+		case KeyStatusActive:
+			if provider.active != nil {
+				return nil, fmt.Errorf("more than one active key: %q and %q", provider.active.ID, key.ID)
+			}
+			if !key.CanSign() {
+				return nil, fmt.Errorf("active key %q has no private key", key.ID)
+			}
+			provider.active = key
+		case KeyStatusVerify, KeyStatusRetired:
+		default:
+			return nil, fmt.Errorf("key %q has unknown status %q", key.ID, key.Status)
+		}
+
+		provider.keys[key.ID] = key
+		provider.order = append(provider.order, key)
+	}
+
+	if provider.active == nil {
+		return nil, fmt.Errorf("no active key configured")
+	}
+
+	return provider, nil
+}
+
+// SigningKey implements KeyProvider.
+func (p *StaticKeyProvider) SigningKey() (*Key, error) {
+	return p.active, nil
+}
+
+// VerificationKey implements KeyProvider.
+func (p *StaticKeyProvider) VerificationKey(kid string) (*Key, error) {
+	key, ok := p.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	if key.Status == KeyStatusRetired {
+		return nil, fmt.Errorf("key %q is retired", kid)
+	}
+
+	return key, nil
+}
+
+// Keys implements KeyProvider.
+func (p *StaticKeyProvider) Keys() []*Key {
+	return p.order
+}
+
+// NewKey builds a key from its private part, its public part, or both.
+// For HS256 the shared secret is passed as signKey.
+func NewKey(id, status, algorithm string, signKey crypto.PrivateKey, verifyKey crypto.PublicKey) (*Key, error) {
+	key := &Key{ID: id, Status: status}
+
+	switch algorithm {
+	case AlgorithmRS256:
+		key.Method = jwt.SigningMethodRS256
+		if private, ok := signKey.(*rsa.PrivateKey); ok {
+			key.signKey = private
+			verifyKey = &private.PublicKey
+		} else if signKey != nil {
+			return nil, fmt.Errorf("key %q: expected an RSA private key", id)
+		}
+		if _, ok := verifyKey.(*rsa.PublicKey); !ok {
+			return nil, fmt.Errorf("key %q: expected an RSA public key", id)
+		}
+	case AlgorithmEdDSA:
+		key.Method = jwt.SigningMethodEdDSA
+		if private, ok := signKey.(ed25519.PrivateKey); ok {
+			key.signKey = private
+			verifyKey = private.Public()
+		} else if signKey != nil {
+			return nil, fmt.Errorf("key %q: expected an Ed25519 private key", id)
+		}
+		if _, ok := verifyKey.(ed25519.PublicKey); !ok {
+			return nil, fmt.Errorf("key %q: expected an Ed25519 public key", id)
+		}
+	case AlgorithmHS256:
+		key.Method = jwt.SigningMethodHS256
+		secret, ok := signKey.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, fmt.Errorf("key %q: expected a non-empty HMAC secret", id)
+		}
+		key.signKey = secret
+		verifyKey = secret
+	default:
+		return nil, fmt.Errorf("key %q has unsupported algorithm %q", id, algorithm)
+	}
+
+	key.verifyKey = verifyKey
+	return key, nil
+}
+
+// LoadKeys reads the PEM keys listed in the config. Without configured keys
+// it falls back to a single HS256 key taken from JWT_SECRET_KEY.
+func LoadKeys(cfg config.JWTConfig) ([]*Key, error) {
+	if len(cfg.Keys) == 0 {
+		secret := os.Getenv(legacySecretEnvKey)
+		if secret == "" {
+			return nil, fmt.Errorf("no jwt keys configured and %s is not set", legacySecretEnvKey)
+		}
+
+		key, err := NewKey(legacyKeyID, KeyStatusActive, AlgorithmHS256, []byte(secret), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*Key{key}, nil
+	}
+
+	keys := make([]*Key, 0, len(cfg.Keys))
+	for _, keyCfg := range cfg.Keys {
+		key, err := loadKey(keyCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func loadKey(cfg config.JWTKey) (*Key, error) {
+	var signKey crypto.PrivateKey
+	var verifyKey crypto.PublicKey
+
+	if cfg.PrivateKeyPath != "" {
+		pem, err := os.ReadFile(cfg.PrivateKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key %q: %w", cfg.KID, err)
+		}
+
+		switch cfg.Algorithm {
+		case AlgorithmRS256:
+			signKey, err = jwt.ParseRSAPrivateKeyFromPEM(pem)
+		case AlgorithmEdDSA:
+			signKey, err = jwt.ParseEdPrivateKeyFromPEM(pem)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key %q: %w", cfg.KID, err)
+		}
+	} else if cfg.PublicKeyPath != "" {
+		pem, err := os.ReadFile(cfg.PublicKeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read public key %q: %w", cfg.KID, err)
+		}
+
+		switch cfg.Algorithm {
+		case AlgorithmRS256:
+			verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(pem)
+		case AlgorithmEdDSA:
+			verifyKey, err = jwt.ParseEdPublicKeyFromPEM(pem)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key %q: %w", cfg.KID, err)
+		}
+	} else {
+		return nil, fmt.Errorf("key %q has neither private_key_path nor public_key_path", cfg.KID)
+	}
+
+	return NewKey(cfg.KID, cfg.Status, cfg.Algorithm, signKey, verifyKey)
+}
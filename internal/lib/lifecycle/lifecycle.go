@@ -0,0 +1,62 @@
+package lifecycle
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Lifecycle tracks whether the server should receive traffic and
+// runs the background workers that must stop before the storage is closed
+type Lifecycle struct {
+	ready atomic.Bool
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// New creates a Lifecycle that is not ready yet
+func New() *Lifecycle {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Lifecycle{ctx: ctx, cancel: cancel}
+}
+
+// Ready reports whether the server accepts new traffic
+func (l *Lifecycle) Ready() bool {
+	return l.ready.Load()
+}
+
+// SetReady marks the server as ready or, before draining, as not ready
+func (l *Lifecycle) SetReady(ready bool) {
+	l.ready.Store(ready)
+}
+
+// Go runs worker in the background until Stop is called.
+// The worker must return soon after its context is done.
+func (l *Lifecycle) Go(worker func(ctx context.Context)) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		worker(l.ctx)
+	}()
+}
+
+// Stop cancels the workers and waits until they have returned or ctx is done
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
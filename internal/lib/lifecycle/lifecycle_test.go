@@ -0,0 +1,63 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	l := New()
+	if l.Ready() {
+		t.Fatal("new lifecycle is ready")
+	}
+
+	l.SetReady(true)
+	if !l.Ready() {
+		t.Fatal("SetReady(true) did not make the lifecycle ready")
+	}
+
+	l.SetReady(false)
+	if l.Ready() {
+		t.Fatal("SetReady(false) did not make the lifecycle not ready")
+	}
+}
+
+func TestStopWaitsForWorkers(t *testing.T) {
+	l := New()
+
+	stopped := make(chan struct{})
+	l.Go(func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		close(stopped)
+	})
+
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("Stop returned before the worker did")
+	}
+}
+
+func TestStopGivesUp(t *testing.T) {
+	l := New()
+
+	release := make(chan struct{})
+	defer close(release)
+	l.Go(func(ctx context.Context) {
+		<-release
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := l.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop with a stuck worker = %v, want DeadlineExceeded", err)
+	}
+}
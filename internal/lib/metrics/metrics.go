@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "restapi"
+
+// Metrics holds the collectors of the service in its own registry.
+// The methods of a nil *Metrics do nothing, so handlers can be built without metrics in tests.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	requestsInFlight prometheus.Gauge
+	queryDuration    *prometheus.HistogramVec
+
+	usersRegistered prometheus.Counter
+	tasksCreated    prometheus.Counter
+	tasksCompleted  prometheus.Counter
+}
+
+// New creates the service metrics; pool reports the connection pool of the storage
+func New(pool StatsSource) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route template, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route template, method and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		requestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "http_requests_in_flight",
+			Help:      "HTTP requests being served.",
+		}),
+		queryDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_query_duration_seconds",
+			Help:      "Storage call latency by storage.Storage method and outcome.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"method", "outcome"}),
+		usersRegistered: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "users_registered_total",
+			Help:      "Users registered.",
+		}),
+		tasksCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_created_total",
+			Help:      "Tasks created.",
+		}),
+		tasksCompleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "tasks_completed_total",
+			Help:      "Tasks moved to the done status.",
+		}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.requestsInFlight,
+		m.queryDuration,
+		m.usersRegistered,
+		m.tasksCreated,
+		m.tasksCompleted,
+		newPoolCollector(pool),
+	)
+
+	return m
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RequestStarted counts a request in flight until the returned function is called with its outcome
+func (m *Metrics) RequestStarted() func(route, method string, status int) {
+	if m == nil {
+		return func(string, string, int) {}
+	}
+
+	start := time.Now()
+	m.requestsInFlight.Inc()
+
+	return func(route, method string, status int) {
+		m.requestsInFlight.Dec()
+
+		labels := prometheus.Labels{"route": route, "method": method, "status": strconv.Itoa(status)}
+		m.requests.With(labels).Inc()
+		m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+// StartCall implements storage.Observer by timing every storage call
+func (m *Metrics) StartCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	start := time.Now()
+
+	return ctx, func(err error) {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		}
+
+		m.queryDuration.WithLabelValues(method, outcome).Observe(time.Since(start).Seconds())
+	}
+}
+
+// UserRegistered counts a new user
+func (m *Metrics) UserRegistered() {
+	if m != nil {
+		m.usersRegistered.Inc()
+	}
+}
+
+// TaskCreated counts a new task
+func (m *Metrics) TaskCreated() {
+	if m != nil {
+		m.tasksCreated.Inc()
+	}
+}
+
+// TaskCompleted counts a task moved to the done status
+func (m *Metrics) TaskCompleted() {
+	if m != nil {
+		m.tasksCompleted.Inc()
+	}
+}
@@ -0,0 +1,73 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type fixedStats sql.DBStats
+
+func (s fixedStats) Stats() sql.DBStats {
+	return sql.DBStats(s)
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body, err := io.ReadAll(rec.Body)
+	if err != nil {
+		t.Fatalf("failed to read metrics: %v", err)
+	}
+
+	return string(body)
+}
+
+func TestMetrics(t *testing.T) {
+	m := New(fixedStats{MaxOpenConnections: 25, InUse: 3})
+
+	done := m.RequestStarted()
+	if body := scrape(t, m); !strings.Contains(body, "restapi_http_requests_in_flight 1") {
+		t.Fatal("request not counted in flight")
+	}
+	done("/tasks/:id", "GET", 200)
+
+	_, finish := m.StartCall(context.Background(), "GetTask")
+	finish(errors.New("boom"))
+
+	m.UserRegistered()
+	m.TaskCreated()
+	m.TaskCreated()
+
+	body := scrape(t, m)
+	for _, want := range []string{
+		`restapi_http_requests_total{method="GET",route="/tasks/:id",status="200"} 1`,
+		`restapi_http_requests_in_flight 0`,
+		`restapi_storage_query_duration_seconds_count{method="GetTask",outcome="error"} 1`,
+		`restapi_users_registered_total 1`,
+		`restapi_tasks_created_total 2`,
+		`restapi_tasks_completed_total 0`,
+		`restapi_db_pool_max_open_connections 25`,
+		`restapi_db_pool_in_use_connections 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+
+	m.RequestStarted()("/", "GET", 200)
+	m.UserRegistered()
+	m.TaskCreated()
+	m.TaskCompleted()
+}
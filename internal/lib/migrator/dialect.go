@@ -0,0 +1,56 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+
+	"restapi/internal/config"
+)
+
+// advisoryLockKey identifies the migration lock among the PostgreSQL advisory locks
+const advisoryLockKey = 7_245_016_113
+
+// dialect holds the statements that differ between databases
+type dialect struct {
+	createTable string
+	insert      string // version, name, checksum
+	delete      string // version
+	lock        func(ctx context.Context, conn *sql.Conn) error
+	unlock      func(ctx context.Context, conn *sql.Conn) error
+}
+
+var dialects = map[string]dialect{
+	config.DriverPostgres: {
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum CHAR(64) NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`,
+		insert: "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+		delete: "DELETE FROM schema_migrations WHERE version = $1",
+		// replicas starting together wait here, then find the migrations applied
+		lock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", advisoryLockKey)
+			return err
+		},
+		unlock: func(ctx context.Context, conn *sql.Conn) error {
+			_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", advisoryLockKey)
+			return err
+		},
+	},
+	// SQLite has no advisory locks. Its database lock serializes the migration transactions,
+	// and a replica that lost a race fails on the schema_migrations primary key instead of applying twice.
+	config.DriverSQLite: {
+		createTable: `CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z')
+		)`,
+		insert: "INSERT INTO schema_migrations (version, name, checksum) VALUES (?1, ?2, ?3)",
+		delete: "DELETE FROM schema_migrations WHERE version = ?1",
+		lock:   func(context.Context, *sql.Conn) error { return nil },
+		unlock: func(context.Context, *sql.Conn) error { return nil },
+	},
+}
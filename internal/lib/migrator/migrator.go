@@ -0,0 +1,378 @@
+package migrator
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrChecksumMismatch  = errors.New("applied migration was modified")
+	ErrMissingMigration  = errors.New("applied migration is missing from the migration directory")
+	ErrNothingToRollBack = errors.New("no applied migration to roll back")
+)
+
+// fileName matches NNNN_name.up.sql and NNNN_name.down.sql; other files are ignored
+var fileName = regexp.MustCompile(`^(\d{4})_(\w+)\.(up|down)\.sql$`)
+
+// Migration is a pair of up and down scripts
+type Migration struct {
+	Version  int64
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // hex encoded sha256 of Up
+}
+
+// Status is a migration and, if it was applied, when
+type Status struct {
+	*Migration
+	AppliedAt *time.Time
+}
+
+// Migrator applies the migrations of a directory and records them in schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	dialect    dialect
+	migrations []*Migration
+}
+
+// New loads the migrations in dir for a database of the given storage driver
+func New(db *sql.DB, driver, dir string) (*Migrator, error) {
+	dialect, ok := dialects[driver]
+	if !ok {
+		return nil, fmt.Errorf("storage driver %q has no migrations", driver)
+	}
+
+	migrations, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, dialect: dialect, migrations: migrations}, nil
+}
+
+// Load reads the migrations in dir ordered by version.
+// Every version needs exactly one up and one down script.
+func Load(dir string) ([]*Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migration directory: %w", err)
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, _ := strconv.ParseInt(match[1], 10, 64)
+
+		script, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration: %w", err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		} else if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %04d has two names: %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			sum := sha256.Sum256(script)
+			migration.Up = string(script)
+			migration.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			migration.Down = string(script)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%s needs both an up and a down script", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	slices.SortFunc(migrations, func(a, b *Migration) int {
+		return int(a.Version - b.Version)
+	})
+
+	return migrations, nil
+}
+
+// Up applies every pending migration in order and returns them
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	var done []*Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down rolls back the latest applied migration and returns it
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	var done *Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		done = m.latest(applied)
+		if done == nil {
+			return ErrNothingToRollBack
+		}
+
+		return m.rollBack(ctx, conn, done)
+	})
+
+	return done, err
+}
+
+// Redo rolls back the latest applied migration and applies it again
+func (m *Migrator) Redo(ctx context.Context) (*Migration, error) {
+	var done *Migration
+
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		done = m.latest(applied)
+		if done == nil {
+			return ErrNothingToRollBack
+		}
+
+		if err := m.rollBack(ctx, conn, done); err != nil {
+			return err
+		}
+
+		return m.apply(ctx, conn, done)
+	})
+
+	return done, err
+}
+
+// Status lists every migration and when it was applied.
+// Like every other operation it fails if an applied migration has drifted.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	err := m.locked(ctx, func(conn *sql.Conn, applied map[int64]time.Time) error {
+		statuses = m.statuses(applied)
+		return nil
+	})
+
+	return statuses, err
+}
+
+// Check is Status without the migration lock, for frequent callers like readiness probes.
+// It may observe a migration that is being applied as pending.
+func (m *Migrator) Check(ctx context.Context) ([]Status, error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	applied, err := m.verify(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.statuses(applied), nil
+}
+
+func (m *Migrator) statuses(applied map[int64]time.Time) []Status {
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if appliedAt, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &appliedAt
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// Pending returns the migrations that are not applied yet
+func Pending(statuses []Status) []*Migration {
+	var pending []*Migration
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+
+	return pending
+}
+
+// locked runs fn on a single connection that holds the migration lock,
+// after checking that the applied migrations match the directory
+func (m *Migrator) locked(ctx context.Context, fn func(conn *sql.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get connection: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.dialect.lock(ctx, conn); err != nil {
+		return fmt.Errorf("failed to take migration lock: %w", err)
+	}
+	defer m.dialect.unlock(context.WithoutCancel(ctx), conn)
+
+	if _, err := conn.ExecContext(ctx, m.dialect.createTable); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	applied, err := m.verify(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, applied)
+}
+
+// verify compares the recorded checksums with the directory and returns when each version was applied
+func (m *Migrator) verify(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var name, checksum string
+		var appliedAt appliedTime
+		if err := rows.Scan(&version, &name, &checksum, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		migration := m.find(version)
+		if migration == nil {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrMissingMigration, version, name)
+		}
+		if migration.Checksum != checksum {
+			return nil, fmt.Errorf("%w: %04d_%s", ErrChecksumMismatch, version, name)
+		}
+
+		applied[version] = appliedAt.Time
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return applied, nil
+}
+
+// apply runs the up script and records it in one transaction
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, migration *Migration) error {
+	return m.inTx(ctx, conn, migration, func(tx *sql.Tx) error {
+		// recording first makes SQLite take its write lock before the script runs
+		if _, err := tx.ExecContext(ctx, m.dialect.insert, migration.Version, migration.Name, migration.Checksum); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, migration.Up)
+		return err
+	})
+}
+
+// rollBack runs the down script and removes its record in one transaction
+func (m *Migrator) rollBack(ctx context.Context, conn *sql.Conn, migration *Migration) error {
+	return m.inTx(ctx, conn, migration, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, m.dialect.delete, migration.Version); err != nil {
+			return err
+		}
+
+		_, err := tx.ExecContext(ctx, migration.Down)
+		return err
+	})
+}
+
+func (m *Migrator) inTx(ctx context.Context, conn *sql.Conn, migration *Migration, fn func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration
+		}
+	}
+
+	return nil
+}
+
+// latest returns the applied migration with the highest version
+func (m *Migrator) latest(applied map[int64]time.Time) *Migration {
+	for _, migration := range slices.Backward(m.migrations) {
+		if _, ok := applied[migration.Version]; ok {
+			return migration
+		}
+	}
+
+	return nil
+}
+
+// appliedTime scans applied_at, a TIMESTAMPTZ in PostgreSQL and TEXT in SQLite
+type appliedTime struct {
+	time.Time
+}
+
+func (t *appliedTime) Scan(src any) error {
+	switch value := src.(type) {
+	case time.Time:
+		t.Time = value
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("failed to parse applied_at %q: %w", value, err)
+		}
+		t.Time = parsed
+	default:
+		return fmt.Errorf("unsupported applied_at type %T", src)
+	}
+
+	return nil
+}
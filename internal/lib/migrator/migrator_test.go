@@ -0,0 +1,223 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"restapi/internal/config"
+	"restapi/internal/models/sqlite"
+)
+
+func openDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sqlite.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func newMigrator(t *testing.T, db *sql.DB, dir string) *Migrator {
+	t.Helper()
+
+	m, err := New(db, config.DriverSQLite, dir)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	return m
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var count int
+	if err := db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?1", name).Scan(&count); err != nil {
+		t.Fatalf("failed to query sqlite_master: %v", err)
+	}
+
+	return count == 1
+}
+
+func TestUpDownRedo(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_a_migration.up.sql":   "CREATE TABLE a (id INTEGER);",
+		"0001_a_migration.down.sql": "DROP TABLE a;",
+		"0002_b_migration.up.sql":   "CREATE TABLE b (id INTEGER);",
+		"0002_b_migration.down.sql": "DROP TABLE b;",
+		"reset.sql":                 "this is not a migration",
+	})
+
+	db := openDB(t)
+	m := newMigrator(t, db, dir)
+	ctx := context.Background()
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if len(applied) != 2 || applied[0].Version != 1 || applied[1].Version != 2 {
+		t.Fatalf("Up applied %v, want versions 1 and 2", applied)
+	}
+	if !tableExists(t, db, "a") || !tableExists(t, db, "b") {
+		t.Fatal("Up did not create the tables")
+	}
+
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up = %v, %v, want nothing applied", applied, err)
+	}
+
+	rolledBack, err := m.Down(ctx)
+	if err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if rolledBack.Version != 2 || tableExists(t, db, "b") {
+		t.Fatalf("Down rolled back %d, want 2 and table b dropped", rolledBack.Version)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if len(statuses) != 2 || statuses[0].AppliedAt == nil || statuses[1].AppliedAt != nil {
+		t.Fatalf("Status = %+v, want 0001 applied and 0002 pending", statuses)
+	}
+	if pending := Pending(statuses); len(pending) != 1 || pending[0].Version != 2 {
+		t.Fatalf("Pending = %v, want 0002", pending)
+	}
+
+	checked, err := m.Check(ctx)
+	if err != nil || len(Pending(checked)) != 1 {
+		t.Fatalf("Check = %+v, %v, want 0002 pending", checked, err)
+	}
+
+	redone, err := m.Redo(ctx)
+	if err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	if redone.Version != 1 || !tableExists(t, db, "a") {
+		t.Fatalf("Redo redid %d, want 1 and table a recreated", redone.Version)
+	}
+
+	if _, err := m.Down(ctx); err != nil {
+		t.Fatalf("Down: %v", err)
+	}
+	if _, err := m.Down(ctx); !errors.Is(err, ErrNothingToRollBack) {
+		t.Fatalf("Down on an empty database = %v, want ErrNothingToRollBack", err)
+	}
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_broken_migration.up.sql":   "CREATE TABLE a (id INTEGER); CREATE TABLE a (id INTEGER);",
+		"0001_broken_migration.down.sql": "DROP TABLE a;",
+	})
+
+	db := openDB(t)
+	m := newMigrator(t, db, dir)
+
+	if _, err := m.Up(context.Background()); err == nil {
+		t.Fatal("Up of a broken migration succeeded")
+	}
+
+	statuses, err := m.Status(context.Background())
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if statuses[0].AppliedAt != nil || tableExists(t, db, "a") {
+		t.Fatal("broken migration was partly applied")
+	}
+}
+
+func TestDrift(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"0001_a_migration.up.sql":   "CREATE TABLE a (id INTEGER);",
+		"0001_a_migration.down.sql": "DROP TABLE a;",
+	})
+
+	db := openDB(t)
+	if _, err := newMigrator(t, db, dir).Up(context.Background()); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+
+	writeFiles(t, dir, map[string]string{"0001_a_migration.up.sql": "CREATE TABLE a (id INTEGER, name TEXT);"})
+
+	if _, err := newMigrator(t, db, dir).Status(context.Background()); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Status after editing an applied migration = %v, want ErrChecksumMismatch", err)
+	}
+	if _, err := newMigrator(t, db, dir).Up(context.Background()); !errors.Is(err, ErrChecksumMismatch) {
+		t.Fatalf("Up after editing an applied migration = %v, want ErrChecksumMismatch", err)
+	}
+
+	for _, name := range []string{"0001_a_migration.up.sql", "0001_a_migration.down.sql"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := newMigrator(t, db, dir).Status(context.Background()); !errors.Is(err, ErrMissingMigration) {
+		t.Fatalf("Status after deleting an applied migration = %v, want ErrMissingMigration", err)
+	}
+}
+
+func TestLoadRequiresDownScript(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"0001_a_migration.up.sql": "CREATE TABLE a (id INTEGER);"})
+
+	if _, err := Load(dir); err == nil {
+		t.Fatal("Load accepted a migration without down script")
+	}
+}
+
+func TestRepositoryMigrations(t *testing.T) {
+	db := openDB(t)
+	m := newMigrator(t, db, "../../../migration/sqlite")
+	ctx := context.Background()
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up: %v", err)
+	}
+	if !tableExists(t, db, "tasks") {
+		t.Fatal("Up did not create the tasks table")
+	}
+
+	for {
+		if _, err := m.Down(ctx); errors.Is(err, ErrNothingToRollBack) {
+			break
+		} else if err != nil {
+			t.Fatalf("Down: %v", err)
+		}
+	}
+	if tableExists(t, db, "tasks") {
+		t.Fatal("rolling everything back left the tasks table")
+	}
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Up after rolling back: %v", err)
+	}
+
+	// the PostgreSQL migrations are only checked for a down script per version
+	if _, err := Load("../../../migration"); err != nil {
+		t.Fatalf("Load of the PostgreSQL migrations: %v", err)
+	}
+}
@@ -0,0 +1,457 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"restapi/internal/errorset"
+
+	"github.com/gin-gonic/gin/binding"
+)
+
+// MaxSize limits the body of a patch request
+const MaxSize = 64 << 10
+
+// media types of the patch documents Apply understands
+const (
+	MergePatchType = "application/merge-patch+json" // RFC 7396
+	JSONPatchType  = "application/json-patch+json"  // RFC 6902
+)
+
+// Apply applies a patch of the given media type to the JSON document doc and returns the
+// patched document. doc is left untouched, a patch that fails in any step changes nothing.
+func Apply(mediaType string, doc, patch []byte) ([]byte, error) {
+	switch mediaType {
+	case MergePatchType:
+		return MergePatch(doc, patch)
+	case JSONPatchType:
+		return JSONPatch(doc, patch)
+	}
+
+	return nil, fmt.Errorf("%w: %q, use %s or %s", errorset.ErrUnsupportedMediaType, mediaType, MergePatchType, JSONPatchType)
+}
+
+// Bind decodes a patched document into v and validates it with the binding rules of v,
+// like gin binds a request body. Members v has no field for are an error.
+func Bind(doc []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(doc))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+
+	return binding.Validator.ValidateStruct(v)
+}
+
+// MergePatch applies a JSON Merge Patch: objects are merged member by member,
+// null removes a member and any other value replaces the target
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	changes, err := decode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", errorset.ErrInvalidPatch, err.Error())
+	}
+
+	return json.Marshal(merge(target, changes))
+}
+
+func merge(target, patch any) any {
+	members, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	object, ok := target.(map[string]any)
+	if !ok {
+		object = map[string]any{}
+	}
+
+	for name, value := range members {
+		if value == nil {
+			delete(object, name)
+			continue
+		}
+		object[name] = merge(object[name], value)
+	}
+
+	return object
+}
+
+// operation is one step of a JSON Patch. Value stays nil when the member is missing,
+// which tells it apart from a null value.
+type operation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// JSONPatch applies the operations of a JSON Patch in order
+func JSONPatch(doc, patch []byte) ([]byte, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode document: %w", err)
+	}
+
+	var operations []operation
+	if err := json.Unmarshal(patch, &operations); err != nil {
+		return nil, fmt.Errorf("%w: must be an array of operations: %s", errorset.ErrInvalidPatch, err.Error())
+	}
+
+	for i, op := range operations {
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func (op operation) apply(doc any) (any, error) {
+	if op.Path == nil {
+		return nil, fmt.Errorf("%w: %q needs a path", errorset.ErrInvalidPatch, op.Op)
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: %q needs a value", errorset.ErrInvalidPatch, op.Op)
+		}
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errorset.ErrInvalidPatch, err.Error())
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		}
+
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, fmt.Errorf("%w: value at %q differs", errorset.ErrPatchTestFailed, *op.Path)
+		}
+		return doc, nil
+
+	case "remove":
+		return remove(doc, path)
+
+	case "move", "copy":
+		if op.From == nil {
+			return nil, fmt.Errorf("%w: %q needs a from", errorset.ErrInvalidPatch, op.Op)
+		}
+		from, err := parsePointer(*op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if op.Op == "copy" {
+			return add(doc, path, clone(value))
+		}
+
+		if isPrefix(from, path) {
+			if len(from) == len(path) {
+				return doc, nil
+			}
+			return nil, fmt.Errorf("%w: can't move %q into itself", errorset.ErrInvalidPatch, *op.From)
+		}
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	}
+
+	return nil, fmt.Errorf("%w: unknown op %q", errorset.ErrInvalidPatch, op.Op)
+}
+
+// parsePointer splits a JSON Pointer (RFC 6901) into its unescaped reference tokens;
+// the empty pointer refers to the whole document
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", errorset.ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		// ~ only escapes ~0 and ~1
+		if strings.Count(token, "~") != strings.Count(token, "~0")+strings.Count(token, "~1") {
+			return nil, fmt.Errorf("%w: path %q has an invalid escape", errorset.ErrInvalidPatch, pointer)
+		}
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// get returns the value path refers to
+func get(doc any, path []string) (any, error) {
+	for i, token := range path {
+		child, err := lookup(doc, token)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, pointer(path[:i+1]))
+		}
+		doc = child
+	}
+
+	return doc, nil
+}
+
+// add inserts value at path: members are set, array elements shifted to the right
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			if token == "-" {
+				return append(node, value), nil
+			}
+			i, err := index(token, len(node))
+			if err != nil {
+				return nil, err
+			}
+			return append(node[:i], append([]any{value}, node[i:]...)...), nil
+		}
+		return nil, errNotContainer
+	})
+}
+
+// replace sets the existing value at path to value
+func replace(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		if _, err := lookup(parent, token); err != nil {
+			return nil, err
+		}
+
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i, _ := index(token, len(node)-1)
+			node[i] = value
+			return node, nil
+		}
+		return nil, errNotContainer
+	})
+}
+
+// remove deletes the existing value at path, array elements are shifted to the left
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: the whole document can't be removed", errorset.ErrInvalidPatch)
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		if _, err := lookup(parent, token); err != nil {
+			return nil, err
+		}
+
+		switch node := parent.(type) {
+		case map[string]any:
+			delete(node, token)
+			return node, nil
+		case []any:
+			i, _ := index(token, len(node)-1)
+			return append(node[:i], node[i+1:]...), nil
+		}
+		return nil, errNotContainer
+	})
+}
+
+// update replaces the parent of the last token of path with what change makes of it.
+// Arrays may grow or shrink, so every container on the way is set again.
+func update(doc any, path []string, change func(parent any, token string) (any, error)) (any, error) {
+	if len(path) == 1 {
+		changed, err := change(doc, path[0])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, pointer(path))
+		}
+		return changed, nil
+	}
+
+	child, err := lookup(doc, path[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", err, pointer(path[:1]))
+	}
+
+	child, err = update(child, path[1:], change)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := doc.(type) {
+	case map[string]any:
+		node[path[0]] = child
+	case []any:
+		i, _ := index(path[0], len(node)-1)
+		node[i] = child
+	}
+
+	return doc, nil
+}
+
+var (
+	errNotContainer = fmt.Errorf("%w: parent is neither object nor array", errorset.ErrInvalidPatch)
+	errNoValue      = fmt.Errorf("%w: no value at path", errorset.ErrInvalidPatch)
+)
+
+// lookup returns the member or element token refers to in doc
+func lookup(doc any, token string) (any, error) {
+	switch node := doc.(type) {
+	case map[string]any:
+		value, ok := node[token]
+		if !ok {
+			return nil, errNoValue
+		}
+		return value, nil
+	case []any:
+		i, err := index(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		return node[i], nil
+	}
+
+	return nil, errNotContainer
+}
+
+// index parses an array index token up to max; leading zeros are not allowed
+func index(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.Trim(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q is not an array index", errorset.ErrInvalidPatch, token)
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil || i > max {
+		return 0, fmt.Errorf("%w: index %s is out of bounds", errorset.ErrInvalidPatch, token)
+	}
+
+	return i, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	return len(prefix) <= len(path) && reflect.DeepEqual(prefix, path[:len(prefix)])
+}
+
+// pointer escapes tokens back into a JSON Pointer, for error messages
+func pointer(tokens []string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	var b strings.Builder
+	for _, token := range tokens {
+		b.WriteString("/" + escaper.Replace(token))
+	}
+
+	return strconv.Quote(b.String())
+}
+
+// decode parses JSON keeping numbers as written, so they survive a round trip unchanged
+func decode(data []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return value, nil
+}
+
+// equal compares JSON values, numbers by their value rather than how they are written
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		x, errA := strconv.ParseFloat(a.String(), 64)
+		y, errB := strconv.ParseFloat(b.String(), 64)
+		return errA == nil && errB == nil && x == y
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			other, ok := b[name]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		b, ok := b.([]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+// clone deep copies a value, so a copied value doesn't share containers with its source
+func clone(value any) any {
+	switch value := value.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(value))
+		for name, member := range value {
+			c[name] = clone(member)
+		}
+		return c
+	case []any:
+		c := make([]any, len(value))
+		for i, element := range value {
+			c[i] = clone(element)
+		}
+		return c
+	}
+
+	return value
+}
@@ -0,0 +1,40 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Store keeps the token buckets, in memory or shared between replicas
+type Store interface {
+	// Take removes a token from the bucket of key. A new bucket starts full with burst tokens
+	// and is refilled at rate tokens per second.
+	Take(ctx context.Context, key string, rate float64, burst int) (Result, error)
+}
+
+// Result of taking a token
+type Result struct {
+	Allowed    bool
+	Remaining  int           // whole tokens left
+	RetryAfter time.Duration // until the next token, zero when allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+// newResult derives the result from the tokens left after taking one, or trying to
+func newResult(allowed bool, tokens, rate float64, burst int) Result {
+	result := Result{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(burst) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// clock is advanced by the test instead of sleeping
+type clock interface {
+	advance(d time.Duration)
+}
+
+type memoryClock struct {
+	now time.Time
+}
+
+func (c *memoryClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type redisClock struct {
+	server *miniredis.Miniredis
+	now    time.Time
+}
+
+func (c *redisClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	c.server.SetTime(c.now)
+	c.server.FastForward(d)
+}
+
+func newMemoryStore(t *testing.T) (Store, clock) {
+	c := &memoryClock{now: time.Unix(1_000_000, 0)}
+	s := NewMemoryStore()
+	s.now = func() time.Time { return c.now }
+
+	return s, c
+}
+
+// newRedisStore runs the store against an in-process Redis stand-in
+func newRedisStore(t *testing.T) (Store, clock) {
+	server := miniredis.RunT(t)
+	c := &redisClock{server: server, now: time.Unix(1_000_000, 0)}
+	server.SetTime(c.now)
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return NewRedisStore(client), c
+}
+
+func TestStores(t *testing.T) {
+	for name, newStore := range map[string]func(t *testing.T) (Store, clock){
+		"memory": newMemoryStore,
+		"redis":  newRedisStore,
+	} {
+		t.Run(name, func(t *testing.T) {
+			s, c := newStore(t)
+			ctx := context.Background()
+
+			// 3 requests at once, then one every 2 seconds
+			const rate, burst = 0.5, 3
+
+			for i := 2; i >= 0; i-- {
+				result, err := s.Take(ctx, "user:1", rate, burst)
+				if err != nil {
+					t.Fatalf("Take: %v", err)
+				}
+				if !result.Allowed || result.Remaining != i {
+					t.Fatalf("Take = %+v, want allowed with %d left", result, i)
+				}
+			}
+
+			result, err := s.Take(ctx, "user:1", rate, burst)
+			if err != nil {
+				t.Fatalf("Take: %v", err)
+			}
+			if result.Allowed || result.RetryAfter != 2*time.Second || result.Reset != 6*time.Second {
+				t.Fatalf("Take on an empty bucket = %+v, want denied, retry after 2s, reset in 6s", result)
+			}
+
+			if result, _ := s.Take(ctx, "user:2", rate, burst); !result.Allowed {
+				t.Fatal("another client shares the bucket")
+			}
+
+			c.advance(2 * time.Second)
+			if result, _ := s.Take(ctx, "user:1", rate, burst); !result.Allowed || result.Remaining != 0 {
+				t.Fatalf("Take after a refill = %+v, want allowed with 0 left", result)
+			}
+
+			c.advance(time.Hour)
+			if result, _ := s.Take(ctx, "user:1", rate, burst); !result.Allowed || result.Remaining != burst-1 {
+				t.Fatalf("Take after idling = %+v, want a full bucket again", result)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSweepsFullBuckets(t *testing.T) {
+	s, c := newMemoryStore(t)
+	memory := s.(*MemoryStore)
+
+	memory.Take(context.Background(), "ip:1", 1, 5)
+	c.advance(sweepInterval)
+	memory.Take(context.Background(), "ip:2", 1, 5)
+
+	if _, ok := memory.buckets["ip:1"]; ok || len(memory.buckets) != 1 {
+		t.Fatalf("buckets %v, want only ip:2 left", memory.buckets)
+	}
+}
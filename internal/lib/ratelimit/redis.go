@@ -0,0 +1,63 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix keeps the buckets apart from other data in a shared Redis
+const keyPrefix = "ratelimit:"
+
+// takeScript refills and takes from the bucket atomically, using the Redis clock so that
+// replicas with skewed clocks agree. The bucket expires once it would be full again.
+var takeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+
+local time = redis.call('TIME')
+local now = tonumber(time[1]) + tonumber(time[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1]) or burst
+local updated = tonumber(bucket[2]) or now
+
+tokens = math.min(burst, tokens + math.max(0, now - updated) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+
+return {allowed, tostring(tokens)}
+`)
+
+// RedisStore keeps the buckets in Redis, or a server speaking its protocol, shared by all replicas
+type RedisStore struct {
+	client redis.Scripter
+}
+
+func NewRedisStore(client redis.Scripter) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Take(ctx context.Context, key string, rate float64, burst int) (Result, error) {
+	reply, err := takeScript.Run(ctx, s.client, []string{keyPrefix + key}, rate, burst).Slice()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to take a token in redis: %w", err)
+	}
+
+	allowed, _ := reply[0].(int64)
+	tokens, err := strconv.ParseFloat(fmt.Sprint(reply[1]), 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to parse the tokens left in redis: %w", err)
+	}
+
+	return newResult(allowed == 1, tokens, rate, burst), nil
+}
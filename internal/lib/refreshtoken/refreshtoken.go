@@ -0,0 +1,29 @@
+package refreshtoken
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"restapi/internal/lib/hashtool"
+)
+
+const tokenSize = 32
+
+// Generate returns a new random refresh token and the hash that should be stored
+func Generate() (raw string, hash string, err error) {
+	key, err := hashtool.GenerateKey(tokenSize)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	raw = base64.RawURLEncoding.EncodeToString(key)
+	return raw, Hash(raw), nil
+}
+
+// Hash returns the hex encoded SHA-256 of a raw refresh token
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,65 @@
+package requestid
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Header carries the request ID in requests and responses
+	Header = "X-Request-ID"
+	// LogKey is the logger attribute of the request ID
+	LogKey = "request_id"
+
+	// maxLength bounds incoming IDs, so clients can't bloat every log line of a request
+	maxLength = 128
+)
+
+type contextKey struct{}
+
+// New returns a random request ID
+func New() string {
+	return uuid.NewString()
+}
+
+// Valid reports whether an incoming ID is safe to log and to echo back:
+// 1 to 128 letters, digits or any of '-', '_', '.', ':'
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+
+	for _, r := range id {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// WithID returns a copy of ctx carrying the request ID
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID of ctx, or "" outside of a request
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// LogAttrs returns the request ID of ctx as logger attribute, or nothing outside of a request
+func LogAttrs(ctx context.Context) []any {
+	id := FromContext(ctx)
+	if id == "" {
+		return nil
+	}
+
+	return []any{slog.String(LogKey, id)}
+}
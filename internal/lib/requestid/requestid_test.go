@@ -0,0 +1,24 @@
+package requestid
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	for id, want := range map[string]bool{
+		"":                                    false,
+		New():                                 true,
+		"client-42_retry.1:a":                 true,
+		"with space":                          false,
+		"line\nbreak":                         false,
+		"<script>":                            false,
+		strings.Repeat("a", maxLength):        true,
+		strings.Repeat("a", maxLength+1):      false,
+		"00-4bf92f3577b34da6a3ce929d0e0e4736": true,
+	} {
+		if got := Valid(id); got != want {
+			t.Errorf("Valid(%q) = %v, want %v", id, got, want)
+		}
+	}
+}
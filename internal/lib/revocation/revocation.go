@@ -0,0 +1,177 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"restapi/internal/errorset"
+)
+
+// Store is the part of the storage the checker reads revocation state from
+type Store interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	GetTokensValidAfter(ctx context.Context, userID int64) (time.Time, error)
+}
+
+type cachedJTI struct {
+	revoked   bool
+	expiresAt time.Time
+}
+
+type cachedUser struct {
+	validAfter time.Time
+	deleted    bool
+	expiresAt  time.Time
+}
+
+// Checker decides whether an access token was revoked. Lookups are cached
+// in process for ttl, so a revocation made by another replica is picked up
+// within ttl while revocations made through this Checker apply immediately.
+type Checker struct {
+	store Store
+	ttl   time.Duration
+
+	mu        sync.Mutex
+	jtis      map[string]cachedJTI
+	users     map[int64]cachedUser
+	lastSweep time.Time
+}
+
+// NewChecker creates a Checker backed by store
+func NewChecker(store Store, ttl time.Duration) *Checker {
+	return &Checker{
+		store:     store,
+		ttl:       ttl,
+		jtis:      make(map[string]cachedJTI),
+		users:     make(map[int64]cachedUser),
+		lastSweep: time.Now(),
+	}
+}
+
+// Check returns errorset.ErrTokenRevoked if the token with the given jti
+// was revoked, or was issued before the user's tokens_valid_after
+func (c *Checker) Check(ctx context.Context, jti string, userID int64, issuedAt time.Time) error {
+	user, err := c.user(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// issued-at has millisecond precision, so a token issued in the same millisecond
+	// as the revocation is rejected as well
+	if user.deleted || (!user.validAfter.IsZero() && issuedAt.UnixMilli() <= user.validAfter.UnixMilli()) {
+		return errorset.ErrTokenRevoked
+	}
+
+	revoked, err := c.jti(ctx, jti)
+	if err != nil {
+		return err
+	}
+
+	if revoked {
+		return errorset.ErrTokenRevoked
+	}
+
+	return nil
+}
+
+// RevokeToken records in the cache that the token with the given jti is revoked.
+// The caller is responsible for persisting the revocation.
+func (c *Checker) RevokeToken(jti string, tokenExpiresAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.jtis[jti] = cachedJTI{revoked: true, expiresAt: tokenExpiresAt}
+}
+
+// RevokeUser records in the cache that tokens issued to the user up to at are revoked.
+// The caller is responsible for persisting the revocation.
+func (c *Checker) RevokeUser(userID int64, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.users[userID] = cachedUser{validAfter: at, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// ForgetUser drops the cached state of a user, e.g. after the user was deleted
+func (c *Checker) ForgetUser(userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.users, userID)
+}
+
+func (c *Checker) user(ctx context.Context, userID int64) (cachedUser, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.users[userID]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached, nil
+	}
+
+	validAfter, err := c.store.GetTokensValidAfter(ctx, userID)
+	if err != nil && !errors.Is(err, errorset.ErrUserNotFound) {
+		return cachedUser{}, fmt.Errorf("failed to check tokens valid after: %w", err)
+	}
+
+	cached = cachedUser{
+		validAfter: validAfter,
+		deleted:    errors.Is(err, errorset.ErrUserNotFound),
+		expiresAt:  now.Add(c.ttl),
+	}
+
+	c.mu.Lock()
+	c.users[userID] = cached
+	c.sweep(now)
+	c.mu.Unlock()
+
+	return cached, nil
+}
+
+func (c *Checker) jti(ctx context.Context, jti string) (bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	cached, ok := c.jtis[jti]
+	c.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.revoked, nil
+	}
+
+	revoked, err := c.store.IsAccessTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+
+	c.mu.Lock()
+	c.jtis[jti] = cachedJTI{revoked: revoked, expiresAt: now.Add(c.ttl)}
+	c.sweep(now)
+	c.mu.Unlock()
+
+	return revoked, nil
+}
+
+// sweep drops expired cache entries at most once per ttl; c.mu must be held
+func (c *Checker) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+
+	for jti, cached := range c.jtis {
+		if !now.Before(cached.expiresAt) {
+			delete(c.jtis, jti)
+		}
+	}
+
+	for userID, cached := range c.users {
+		if !now.Before(cached.expiresAt) {
+			delete(c.users, userID)
+		}
+	}
+
+	c.lastSweep = now
+}
@@ -0,0 +1,104 @@
+package revocation
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"restapi/internal/errorset"
+)
+
+type store struct {
+	revoked    map[string]bool
+	validAfter map[int64]time.Time
+	calls      int
+}
+
+func (s *store) IsAccessTokenRevoked(_ context.Context, jti string) (bool, error) {
+	s.calls++
+	return s.revoked[jti], nil
+}
+
+func (s *store) GetTokensValidAfter(_ context.Context, userID int64) (time.Time, error) {
+	s.calls++
+	validAfter, ok := s.validAfter[userID]
+	if !ok {
+		return time.Time{}, errorset.ErrUserNotFound
+	}
+
+	return validAfter, nil
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		jti      string
+		userID   int64
+		issuedAt time.Time
+		expected error
+	}{
+		{name: "valid", jti: "a", userID: 1, issuedAt: now, expected: nil},
+		{name: "revoked jti", jti: "revoked", userID: 1, issuedAt: now, expected: errorset.ErrTokenRevoked},
+		{name: "issued before valid after", jti: "b", userID: 2, issuedAt: now.Add(-2 * time.Hour), expected: errorset.ErrTokenRevoked},
+		{name: "issued after valid after", jti: "c", userID: 2, issuedAt: now, expected: nil},
+		{name: "deleted user", jti: "d", userID: 3, issuedAt: now, expected: errorset.ErrTokenRevoked},
+	}
+
+	s := &store{
+		revoked:    map[string]bool{"revoked": true},
+		validAfter: map[int64]time.Time{1: {}, 2: now.Add(-time.Hour)},
+	}
+	checker := NewChecker(s, time.Minute)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checker.Check(context.Background(), tc.jti, tc.userID, tc.issuedAt)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestCheckUsesCache(t *testing.T) {
+	s := &store{revoked: map[string]bool{}, validAfter: map[int64]time.Time{1: {}}}
+	checker := NewChecker(s, time.Minute)
+	issuedAt := time.Now().Add(-time.Second)
+
+	if err := checker.Check(context.Background(), "a", 1, issuedAt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := checker.Check(context.Background(), "a", 1, issuedAt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.calls != 2 {
+		t.Errorf("expected 2 store calls, got %d", s.calls)
+	}
+
+	checker.RevokeToken("a", time.Now().Add(time.Hour))
+	if err := checker.Check(context.Background(), "a", 1, issuedAt); !errors.Is(err, errorset.ErrTokenRevoked) {
+		t.Errorf("expected revoked token after RevokeToken, got %v", err)
+	}
+
+	checker.RevokeUser(1, time.Now())
+	if err := checker.Check(context.Background(), "b", 1, issuedAt); !errors.Is(err, errorset.ErrTokenRevoked) {
+		t.Errorf("expected revoked token after RevokeUser, got %v", err)
+	}
+}
+
+func TestCheckWithinTheSecondOfRevocation(t *testing.T) {
+	revokedAt := time.Date(2026, 1, 2, 3, 4, 5, 500*int(time.Millisecond), time.UTC)
+	s := &store{revoked: map[string]bool{}, validAfter: map[int64]time.Time{1: revokedAt}}
+	checker := NewChecker(s, time.Minute)
+
+	if err := checker.Check(context.Background(), "before", 1, revokedAt.Add(-100*time.Millisecond)); !errors.Is(err, errorset.ErrTokenRevoked) {
+		t.Errorf("token issued before the revocation: expected revoked, got %v", err)
+	}
+	// e.g. a login right after logging out everywhere
+	if err := checker.Check(context.Background(), "after", 1, revokedAt.Add(100*time.Millisecond)); err != nil {
+		t.Errorf("token issued after the revocation in the same second: unexpected error %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package servicesig
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// NonceStore records nonces until they expire, in memory or shared between replicas
+type NonceStore interface {
+	// Seen reports whether nonce was already recorded and records it otherwise
+	Seen(ctx context.Context, nonce string, expiresAt time.Time) (bool, error)
+}
+
+// NonceCache remembers nonces in memory until they expire. Replicas don't share it,
+// so a request replayed against another replica is accepted there once more.
+type NonceCache struct {
+	sweepEvery time.Duration
+
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	lastSweep time.Time
+}
+
+// NewNonceCache creates a NonceCache that drops expired nonces at most once per sweepEvery
+func NewNonceCache(sweepEvery time.Duration) *NonceCache {
+	return &NonceCache{
+		sweepEvery: sweepEvery,
+		nonces:     make(map[string]time.Time),
+		lastSweep:  time.Now(),
+	}
+}
+
+func (n *NonceCache) Seen(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	now := time.Now()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.sweepEvery > 0 && now.Sub(n.lastSweep) >= n.sweepEvery {
+		for known, knownExpiresAt := range n.nonces {
+			if !now.Before(knownExpiresAt) {
+				delete(n.nonces, known)
+			}
+		}
+		n.lastSweep = now
+	}
+
+	if knownExpiresAt, ok := n.nonces[nonce]; ok && now.Before(knownExpiresAt) {
+		return true, nil
+	}
+
+	n.nonces[nonce] = expiresAt
+	return false, nil
+}
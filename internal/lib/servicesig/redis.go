@@ -0,0 +1,33 @@
+package servicesig
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// nonceKeyPrefix keeps the nonces apart from other data in a shared Redis
+const nonceKeyPrefix = "nonce:"
+
+// RedisNonceStore keeps the nonces in Redis, or a server speaking its protocol, shared by all replicas
+type RedisNonceStore struct {
+	client redis.Cmdable
+}
+
+func NewRedisNonceStore(client redis.Cmdable) *RedisNonceStore {
+	return &RedisNonceStore{client: client}
+}
+
+func (s *RedisNonceStore) Seen(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	// Redis takes no expiry below a millisecond, and no expiry at all would keep the nonce forever
+	ttl := max(time.Until(expiresAt), time.Millisecond)
+
+	recorded, err := s.client.SetNX(ctx, nonceKeyPrefix+nonce, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to record the nonce in redis: %w", err)
+	}
+
+	return !recorded, nil
+}
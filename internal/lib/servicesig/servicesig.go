@@ -0,0 +1,165 @@
+package servicesig
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"restapi/internal/config"
+)
+
+const (
+	APIKeyHeader    = "X-API-Key"
+	KeyIDHeader     = "X-Key-Id"
+	TimestampHeader = "X-Timestamp"
+	NonceHeader     = "X-Nonce"
+	SignatureHeader = "X-Signature"
+)
+
+var (
+	ErrUnknownKey       = errors.New("unknown key")
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrStaleTimestamp   = errors.New("timestamp outside of allowed clock skew")
+	ErrNonceReused      = errors.New("nonce already used")
+	ErrMissingHeaders   = errors.New("missing signature headers")
+	ErrNonceStore       = errors.New("failed to check the nonce")
+)
+
+// SignedRequest holds the parts of a request covered by the signature
+type SignedRequest struct {
+	Method    string
+	URI       string // path with the raw query, e.g. /user?x=1
+	Timestamp string // unix seconds
+	Nonce     string
+	Body      []byte
+}
+
+// Verifier authenticates calling services by API key or HMAC signature
+type Verifier struct {
+	apiKeys      map[string]string // hex sha256 of the key -> service name
+	hmacSecrets  map[string][]byte
+	maxClockSkew time.Duration
+	nonces       NonceStore
+}
+
+// NewVerifier reads the configured keys; HMAC secrets are taken from the
+// environment variables named in the config. Signed requests record their nonces in nonces.
+func NewVerifier(cfg config.InternalAuth, nonces NonceStore) (*Verifier, error) {
+	v := &Verifier{
+		apiKeys:      make(map[string]string, len(cfg.APIKeys)),
+		hmacSecrets:  make(map[string][]byte, len(cfg.HMACKeys)),
+		maxClockSkew: cfg.MaxClockSkew,
+		nonces:       nonces,
+	}
+
+	for _, key := range cfg.APIKeys {
+		hash := strings.ToLower(key.KeyHash)
+		if len(hash) != sha256.Size*2 {
+			return nil, fmt.Errorf("api key %q: key_hash must be a hex encoded sha256", key.Name)
+		}
+
+		v.apiKeys[hash] = key.Name
+	}
+
+	for _, key := range cfg.HMACKeys {
+		secret := os.Getenv(key.SecretEnv)
+		if secret == "" {
+			return nil, fmt.Errorf("hmac key %q: %s is not set", key.KeyID, key.SecretEnv)
+		}
+
+		v.hmacSecrets[key.KeyID] = []byte(secret)
+	}
+
+	return v, nil
+}
+
+// VerifyAPIKey returns the name of the service owning the key
+func (v *Verifier) VerifyAPIKey(key string) (string, error) {
+	sum := sha256.Sum256([]byte(key))
+	hash := hex.EncodeToString(sum[:])
+
+	// compare against every key so the timing does not depend on which one matched
+	var service string
+	for known, name := range v.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(hash)) == 1 {
+			service = name
+		}
+	}
+
+	if service == "" {
+		return "", ErrUnknownKey
+	}
+
+	return service, nil
+}
+
+// VerifySignature checks an HMAC signed request and returns the key ID that signed it.
+// Each nonce is accepted once, on every replica sharing the nonce store.
+func (v *Verifier) VerifySignature(ctx context.Context, keyID, signature string, req SignedRequest) (string, error) {
+	if keyID == "" || signature == "" || req.Timestamp == "" || req.Nonce == "" {
+		return "", ErrMissingHeaders
+	}
+
+	secret, ok := v.hmacSecrets[keyID]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+
+	unix, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return "", ErrStaleTimestamp
+	}
+
+	timestamp := time.Unix(unix, 0)
+	if skew := time.Since(timestamp); skew > v.maxClockSkew || skew < -v.maxClockSkew {
+		return "", ErrStaleTimestamp
+	}
+
+	expected, err := hex.DecodeString(Sign(secret, req))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	given, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(expected, given) {
+		return "", ErrInvalidSignature
+	}
+
+	// only record the nonce once the signature is valid, so forged requests cannot burn nonces
+	// a nonce only has to be remembered while its timestamp is acceptable
+	seen, err := v.nonces.Seen(ctx, keyID+":"+req.Nonce, timestamp.Add(v.maxClockSkew))
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrNonceStore, err)
+	}
+	if seen {
+		return "", ErrNonceReused
+	}
+
+	return keyID, nil
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of the canonical form of req:
+//
+//	METHOD \n URI \n TIMESTAMP \n NONCE \n hex(sha256(BODY))
+func Sign(secret []byte, req SignedRequest) string {
+	bodyHash := sha256.Sum256(req.Body)
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strings.Join([]string{
+		strings.ToUpper(req.Method),
+		req.URI,
+		req.Timestamp,
+		req.Nonce,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
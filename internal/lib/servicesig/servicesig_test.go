@@ -0,0 +1,173 @@
+package servicesig
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"restapi/internal/config"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+const secretEnv = "SERVICESIG_TEST_SECRET"
+
+func newVerifier(t *testing.T) *Verifier {
+	t.Helper()
+	t.Setenv(secretEnv, "shared-secret")
+
+	sum := sha256.Sum256([]byte("api-key"))
+
+	verifier, err := NewVerifier(config.InternalAuth{
+		APIKeys:      []config.APIKey{{Name: "signup", KeyHash: hex.EncodeToString(sum[:])}},
+		HMACKeys:     []config.HMACKey{{KeyID: "signup", SecretEnv: secretEnv}},
+		MaxClockSkew: time.Minute,
+	}, NewNonceCache(2*time.Minute))
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	return verifier
+}
+
+func TestVerifyAPIKey(t *testing.T) {
+	verifier := newVerifier(t)
+
+	if service, err := verifier.VerifyAPIKey("api-key"); err != nil || service != "signup" {
+		t.Errorf("expected signup, got %q, %v", service, err)
+	}
+
+	if _, err := verifier.VerifyAPIKey("wrong-key"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("expected ErrUnknownKey, got %v", err)
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	signed := func(req SignedRequest) string {
+		return Sign([]byte("shared-secret"), req)
+	}
+
+	tests := []struct {
+		name      string
+		keyID     string
+		req       SignedRequest
+		signature func(req SignedRequest) string
+		expected  error
+	}{
+		{
+			name:      "valid",
+			keyID:     "signup",
+			req:       SignedRequest{Method: "POST", URI: "/user", Timestamp: now, Nonce: "n1", Body: []byte(`{}`)},
+			signature: signed,
+		},
+		{
+			name:      "unknown key",
+			keyID:     "other",
+			req:       SignedRequest{Method: "POST", URI: "/user", Timestamp: now, Nonce: "n2"},
+			signature: signed,
+			expected:  ErrUnknownKey,
+		},
+		{
+			name:      "stale timestamp",
+			keyID:     "signup",
+			req:       SignedRequest{Method: "POST", URI: "/user", Timestamp: stale, Nonce: "n3"},
+			signature: signed,
+			expected:  ErrStaleTimestamp,
+		},
+		{
+			name:  "tampered body",
+			keyID: "signup",
+			req:   SignedRequest{Method: "POST", URI: "/user", Timestamp: now, Nonce: "n4", Body: []byte(`{"admin":true}`)},
+			signature: func(req SignedRequest) string {
+				req.Body = []byte(`{}`)
+				return signed(req)
+			},
+			expected: ErrInvalidSignature,
+		},
+		{
+			name:      "missing nonce",
+			keyID:     "signup",
+			req:       SignedRequest{Method: "POST", URI: "/user", Timestamp: now},
+			signature: signed,
+			expected:  ErrMissingHeaders,
+		},
+	}
+
+	verifier := newVerifier(t)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := verifier.VerifySignature(context.Background(), tc.keyID, tc.signature(tc.req), tc.req)
+			if !errors.Is(err, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+}
+
+func TestVerifySignatureRejectsReplay(t *testing.T) {
+	verifier := newVerifier(t)
+
+	req := SignedRequest{Method: "POST", URI: "/user", Timestamp: strconv.FormatInt(time.Now().Unix(), 10), Nonce: "once"}
+	signature := Sign([]byte("shared-secret"), req)
+
+	if _, err := verifier.VerifySignature(context.Background(), "signup", signature, req); err != nil {
+		t.Fatalf("first request should pass: %v", err)
+	}
+
+	if _, err := verifier.VerifySignature(context.Background(), "signup", signature, req); !errors.Is(err, ErrNonceReused) {
+		t.Errorf("expected ErrNonceReused, got %v", err)
+	}
+}
+
+func TestVerifySignatureRejectsReplayOnAnotherReplica(t *testing.T) {
+	t.Setenv(secretEnv, "shared-secret")
+
+	server := miniredis.RunT(t)
+	cfg := config.InternalAuth{
+		HMACKeys:     []config.HMACKey{{KeyID: "signup", SecretEnv: secretEnv}},
+		MaxClockSkew: time.Minute,
+	}
+
+	// two replicas sharing the nonces through Redis
+	var replicas []*Verifier
+	for range 2 {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+
+		verifier, err := NewVerifier(cfg, NewRedisNonceStore(client))
+		if err != nil {
+			t.Fatalf("failed to create verifier: %v", err)
+		}
+		replicas = append(replicas, verifier)
+	}
+
+	req := SignedRequest{Method: "POST", URI: "/user", Timestamp: strconv.FormatInt(time.Now().Unix(), 10), Nonce: "once"}
+	signature := Sign([]byte("shared-secret"), req)
+
+	if _, err := replicas[0].VerifySignature(context.Background(), "signup", signature, req); err != nil {
+		t.Fatalf("first request should pass: %v", err)
+	}
+
+	if _, err := replicas[1].VerifySignature(context.Background(), "signup", signature, req); !errors.Is(err, ErrNonceReused) {
+		t.Errorf("expected ErrNonceReused on the other replica, got %v", err)
+	}
+
+	// the nonce is forgotten once its timestamp is no longer acceptable
+	if ttl := server.TTL(nonceKeyPrefix + "signup:once"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("nonce expires in %v, want within the clock skew", ttl)
+	}
+
+	server.Close()
+	if _, err := replicas[1].VerifySignature(context.Background(), "signup", signature, req); !errors.Is(err, ErrNonceStore) {
+		t.Errorf("expected ErrNonceStore with redis down, got %v", err)
+	}
+}
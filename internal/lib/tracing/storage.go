@@ -0,0 +1,48 @@
+package tracing
+
+import (
+	"context"
+
+	"restapi/internal/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// StorageTracer implements storage.Observer with a child span per storage call
+type StorageTracer struct {
+	tracer trace.Tracer
+	system attribute.KeyValue
+}
+
+// NewStorageTracer creates the tracer of the storage built for driver
+func NewStorageTracer(driver string) *StorageTracer {
+	system := semconv.DBSystemKey.String(driver)
+	switch driver {
+	case config.DriverPostgres:
+		system = semconv.DBSystemPostgreSQL
+	case config.DriverSQLite:
+		system = semconv.DBSystemSqlite
+	}
+
+	return &StorageTracer{tracer: otel.Tracer(TracerName), system: system}
+}
+
+// StartCall opens a span named after the storage method, which is also the statement name
+func (s *StorageTracer) StartCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	ctx, span := s.tracer.Start(ctx, method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(s.system, semconv.DBOperationName(method)),
+	)
+
+	return ctx, func(err error) {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
@@ -0,0 +1,64 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"restapi/internal/config"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestStorageTracer(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := NewStorageTracer(config.DriverPostgres)
+	s.tracer = provider.Tracer(TracerName)
+
+	ctx, parent := provider.Tracer(TracerName).Start(context.Background(), "GET /tasks/:id")
+
+	callCtx, done := s.StartCall(ctx, "GetTask")
+	if attrs := LogAttrs(callCtx); len(attrs) != 2 {
+		t.Fatalf("LogAttrs in a span = %v, want trace and span ID", attrs)
+	}
+	done(errors.New("boom"))
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+
+	call := spans[0]
+	if call.Name() != "GetTask" || call.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Fatalf("storage span %q with parent %v, want GetTask under the request span", call.Name(), call.Parent().SpanID())
+	}
+	if call.Status().Code != codes.Error {
+		t.Fatalf("storage span status %v, want Error", call.Status())
+	}
+
+	attrs := map[string]string{}
+	for _, attr := range call.Attributes() {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs[string(semconv.DBSystemKey)] != "postgresql" || attrs[string(semconv.DBOperationNameKey)] != "GetTask" {
+		t.Fatalf("storage span attributes %v, want db.system postgresql and db.operation.name GetTask", attrs)
+	}
+}
+
+func TestLogAttrsOutsideTrace(t *testing.T) {
+	if attrs := LogAttrs(context.Background()); attrs != nil {
+		t.Fatalf("LogAttrs without a span = %v, want nothing", attrs)
+	}
+}
+
+func TestSetupRejectsUnknownExporter(t *testing.T) {
+	if _, err := Setup(context.Background(), config.TracingConfig{Exporter: "zipkin"}); err == nil {
+		t.Fatal("Setup accepted an unknown exporter")
+	}
+}
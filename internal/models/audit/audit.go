@@ -0,0 +1,25 @@
+package audit
+
+import "time"
+
+const (
+	ActionListUsers      = "users.list"
+	ActionDisableUser    = "users.disable"
+	ActionEnableUser     = "users.enable"
+	ActionSetUserRole    = "users.set_role"
+	ActionListUserTasks  = "tasks.list"
+	ActionDeleteUserTask = "tasks.delete"
+	ActionListRoles      = "roles.list"
+	ActionSaveRole       = "roles.save"
+)
+
+// Entry records an action an admin took
+type Entry struct {
+	AuditLogID   int64     `json:"auditLogId"`
+	ActorID      int64     `json:"actorId"`
+	Action       string    `json:"action"`
+	TargetUserID *int64    `json:"targetUserId,omitempty"`
+	TargetTaskID *int64    `json:"targetTaskId,omitempty"`
+	Details      string    `json:"details,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
@@ -0,0 +1,20 @@
+package idempotency
+
+import "time"
+
+// Record is the response to the first request sent with an Idempotency-Key,
+// replayed when the client retries the same request with the same key
+type Record struct {
+	Scope       string // user or service the key belongs to, such as user:42
+	Key         string
+	RequestHash string // SHA-256 of the method, path and body of the first request
+	StatusCode  int    // zero while the first request is in progress
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// Done reports whether the response of the first request is stored
+func (r *Record) Done() bool {
+	return r.StatusCode != 0
+}
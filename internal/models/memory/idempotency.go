@@ -0,0 +1,99 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/idempotency"
+)
+
+// idempotencyKeyID keys the idempotency records
+type idempotencyKeyID struct {
+	scope, key string
+}
+
+// SaveIdempotencyKey reserves an idempotency key while its first request runs.
+// An expired key is taken over, a live one is reported as errorset.ErrIdempotencyKeyExists.
+func (m *Memory) SaveIdempotencyKey(ctx context.Context, record *idempotency.Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := idempotencyKeyID{record.Scope, record.Key}
+	now := time.Now()
+
+	if existing, ok := m.idempotencyKeys[id]; ok && existing.ExpiresAt.After(now) {
+		return errorset.ErrIdempotencyKeyExists
+	}
+
+	m.idempotencyKeys[id] = &idempotency.Record{
+		Scope:       record.Scope,
+		Key:         record.Key,
+		RequestHash: record.RequestHash,
+		CreatedAt:   now,
+		ExpiresAt:   record.ExpiresAt,
+	}
+
+	return nil
+}
+
+// GetIdempotencyKey retrieves a live idempotency key
+func (m *Memory) GetIdempotencyKey(ctx context.Context, scope, key string) (*idempotency.Record, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	record, ok := m.idempotencyKeys[idempotencyKeyID{scope, key}]
+	if !ok || !record.ExpiresAt.After(time.Now()) {
+		return nil, errorset.ErrIdempotencyKeyNotFound
+	}
+
+	result := *record
+	result.Body = slices.Clone(record.Body)
+
+	return &result, nil
+}
+
+// CompleteIdempotencyKey stores the response of the first request of an idempotency key
+func (m *Memory) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	record, ok := m.idempotencyKeys[idempotencyKeyID{scope, key}]
+	if !ok {
+		return errorset.ErrIdempotencyKeyNotFound
+	}
+
+	record.StatusCode = statusCode
+	record.Body = slices.Clone(body)
+
+	return nil
+}
+
+// DeleteIdempotencyKey releases an idempotency key, so the request can be retried
+func (m *Memory) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotencyKeys, idempotencyKeyID{scope, key})
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes the expired idempotency keys
+func (m *Memory) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	var deleted int64
+	for id, record := range m.idempotencyKeys {
+		if !record.ExpiresAt.After(now) {
+			delete(m.idempotencyKeys, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,282 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/hashtool"
+	"restapi/internal/models/audit"
+	"restapi/internal/models/idempotency"
+	"restapi/internal/models/task"
+	"restapi/internal/models/token"
+	"restapi/internal/models/user"
+	"restapi/internal/storage"
+)
+
+// Memory implements the Storage interface in process memory.
+// It is meant for tests and local development; nothing survives a restart.
+type Memory struct {
+	mu sync.RWMutex
+
+	users            map[int64]*user.User
+	tokensValidAfter map[int64]time.Time
+	roles            map[string]bool
+	auditLogs        []*audit.Entry
+	tasks            map[int64]*task.Task
+	refreshTokens    map[string]*token.RefreshToken // keyed by token hash
+	revokedTokens    map[string]revokedToken        // keyed by jti
+	idempotencyKeys  map[idempotencyKeyID]*idempotency.Record
+
+	lastUserID         int64
+	lastTaskID         int64
+	lastRefreshTokenID int64
+}
+
+// NewMemory creates an empty Memory with the built-in roles
+func NewMemory() storage.Storage {
+	return &Memory{
+		users:            make(map[int64]*user.User),
+		tokensValidAfter: make(map[int64]time.Time),
+		roles:            map[string]bool{user.RoleUser: true, user.RoleAdmin: true},
+		tasks:            make(map[int64]*task.Task),
+		refreshTokens:    make(map[string]*token.RefreshToken),
+		revokedTokens:    make(map[string]revokedToken),
+		idempotencyKeys:  make(map[idempotencyKeyID]*idempotency.Record),
+	}
+}
+
+// SaveUser stores a new user with a hashed password
+func (m *Memory) SaveUser(ctx context.Context, username, password string) (int64, error) {
+	hashedPassword, err := hashtool.BcryptHashing(password)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.findUser(username) != nil {
+		return 0, errorset.ErrDuplicateUser
+	}
+
+	m.lastUserID++
+	m.users[m.lastUserID] = &user.User{
+		UserID:    m.lastUserID,
+		UserName:  username,
+		Password:  hashedPassword,
+		Role:      user.RoleUser,
+		CreatedAt: time.Now(),
+	}
+
+	return m.lastUserID, nil
+}
+
+// GetUserByID retrieves a user by key
+func (m *Memory) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return nil, errorset.ErrUserNotFound
+	}
+
+	return copyUser(u), nil
+}
+
+// GetUserByUsername retrieves a user by username
+func (m *Memory) GetUserByUsername(ctx context.Context, username string) (*user.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	u := m.findUser(username)
+	if u == nil {
+		return nil, errorset.ErrUserNotFound
+	}
+
+	return copyUser(u), nil
+}
+
+// UsernameExists checks if a user with the given username exists
+func (m *Memory) UsernameExists(ctx context.Context, name string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.findUser(name) != nil, nil
+}
+
+// UpdateUsername renames a user
+func (m *Memory) UpdateUsername(ctx context.Context, id int64, username string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return errorset.ErrUserNotFound
+	}
+
+	if other := m.findUser(username); other != nil && other.UserID != id {
+		return errorset.ErrDuplicateUser
+	}
+
+	u.UserName = username
+
+	return nil
+}
+
+// UpdateUserPassword changes the password of a user.
+// Changing the password also invalidates every token issued to the user.
+func (m *Memory) UpdateUserPassword(ctx context.Context, id int64, password string) error {
+	hashedPassword, err := hashtool.BcryptHashing(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return errorset.ErrUserNotFound
+	}
+
+	u.Password = hashedPassword
+	m.revokeUserTokens(id)
+
+	return nil
+}
+
+// DeleteUser deletes a user together with their tasks and tokens
+func (m *Memory) DeleteUser(ctx context.Context, id int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[id]; !ok {
+		return errorset.ErrUserNotFound
+	}
+
+	delete(m.users, id)
+	delete(m.tokensValidAfter, id)
+
+	for taskID, t := range m.tasks {
+		if t.UserID == id {
+			delete(m.tasks, taskID)
+		}
+	}
+
+	for hash, refreshToken := range m.refreshTokens {
+		if refreshToken.UserID == id {
+			delete(m.refreshTokens, hash)
+		}
+	}
+
+	for jti, revoked := range m.revokedTokens {
+		if revoked.userID == id {
+			delete(m.revokedTokens, jti)
+		}
+	}
+
+	return nil
+}
+
+// GetUsers retrieves every user ordered by key
+func (m *Memory) GetUsers(ctx context.Context) ([]*user.User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]*user.User, 0, len(m.users))
+	for _, u := range m.users {
+		u := copyUser(u)
+		u.Password = ""
+		users = append(users, u)
+	}
+
+	slices.SortFunc(users, func(a, b *user.User) int {
+		return cmp.Compare(a.UserID, b.UserID)
+	})
+
+	return users, nil
+}
+
+// SetUserDisabled disables or enables a user.
+// Disabling also invalidates every token issued to the user.
+func (m *Memory) SetUserDisabled(ctx context.Context, id int64, disabled bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return errorset.ErrUserNotFound
+	}
+
+	if !disabled {
+		u.DisabledAt = nil
+		return nil
+	}
+
+	if u.DisabledAt == nil {
+		now := time.Now()
+		u.DisabledAt = &now
+	}
+	m.revokeUserTokens(id)
+
+	return nil
+}
+
+// SetUserRole changes the role of a user.
+// Tokens issued so far carry the old role, so they are invalidated.
+func (m *Memory) SetUserRole(ctx context.Context, id int64, role string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	u, ok := m.users[id]
+	if !ok {
+		return errorset.ErrUserNotFound
+	}
+
+	if !m.roles[role] {
+		return errorset.ErrRoleNotFound
+	}
+
+	u.Role = role
+	m.revokeUserTokens(id)
+
+	return nil
+}
+
+// Ping always succeeds
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Stats returns empty statistics, there is no connection pool
+func (m *Memory) Stats() sql.DBStats {
+	return sql.DBStats{}
+}
+
+// Close does nothing; the data lives as long as the Memory
+func (m *Memory) Close() error {
+	return nil
+}
+
+// findUser returns the stored user with username; m.mu must be held
+func (m *Memory) findUser(username string) *user.User {
+	for _, u := range m.users {
+		if u.UserName == username {
+			return u
+		}
+	}
+
+	return nil
+}
+
+func copyUser(u *user.User) *user.User {
+	c := *u
+	return &c
+}
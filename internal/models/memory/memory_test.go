@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"testing"
+
+	"restapi/internal/storage"
+	"restapi/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		return NewMemory()
+	})
+}
@@ -0,0 +1,50 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/audit"
+)
+
+// GetRoles retrieves every role name in alphabetical order
+func (m *Memory) GetRoles(ctx context.Context) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	roles := make([]string, 0, len(m.roles))
+	for role := range m.roles {
+		roles = append(roles, role)
+	}
+	slices.Sort(roles)
+
+	return roles, nil
+}
+
+// SaveRole stores a new role
+func (m *Memory) SaveRole(ctx context.Context, role string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.roles[role] {
+		return errorset.ErrDuplicateRole
+	}
+
+	m.roles[role] = true
+	return nil
+}
+
+// SaveAuditLog stores a new audit log entry
+func (m *Memory) SaveAuditLog(ctx context.Context, entry *audit.Entry) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored := *entry
+	stored.AuditLogID = int64(len(m.auditLogs) + 1)
+	stored.CreatedAt = time.Now()
+	m.auditLogs = append(m.auditLogs, &stored)
+
+	return stored.AuditLogID, nil
+}
@@ -0,0 +1,326 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/task"
+)
+
+// SaveTask stores a new task of an existing user
+func (m *Memory) SaveTask(ctx context.Context, newTask *task.Task) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[newTask.UserID]; !ok {
+		return 0, errorset.ErrUserNotFound
+	}
+
+	now := time.Now()
+	stored := &task.Task{
+		UserID:      newTask.UserID,
+		TaskContent: newTask.TaskContent,
+		Status:      newTask.Status,
+		Priority:    newTask.Priority,
+		DueAt:       copyTime(newTask.DueAt),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+
+	if stored.Status == "" {
+		stored.Status = task.StatusTodo
+	}
+	if stored.Status == task.StatusDone {
+		stored.CompletedAt = &now
+	}
+
+	m.lastTaskID++
+	stored.TaskID = m.lastTaskID
+	m.tasks[stored.TaskID] = stored
+
+	return stored.TaskID, nil
+}
+
+// GetTasksByUserID retrieves one page of the tasks of a user matching the query
+func (m *Memory) GetTasksByUserID(ctx context.Context, userID int64, query task.Query) (*task.Page, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, errorset.ErrUserNotFound
+	}
+
+	matched := []*task.Task{}
+	for _, t := range m.tasks {
+		if t.UserID == userID && t.DeletedAt == nil && query.Matches(t) && query.After(t) {
+			matched = append(matched, copyTask(t))
+		}
+	}
+	slices.SortFunc(matched, query.Compare)
+
+	page := &task.Page{Tasks: matched}
+	if len(matched) > query.Limit {
+		page.Tasks = matched[:query.Limit]
+		page.NextCursor = task.EncodeCursor(page.Tasks[query.Limit-1], query.Sort)
+	}
+
+	return page, nil
+}
+
+// SearchTasks ranks the tasks of a user with the portable task.Search
+func (m *Memory) SearchTasks(ctx context.Context, userID int64, query task.SearchQuery) ([]*task.SearchResult, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return nil, errorset.ErrUserNotFound
+	}
+
+	tasks := []*task.Task{}
+	for _, t := range m.tasks {
+		if t.UserID == userID && t.DeletedAt == nil {
+			tasks = append(tasks, copyTask(t))
+		}
+	}
+
+	return task.Search(tasks, query), nil
+}
+
+// GetTaskByTaskID retrieves a task owned by userID and not in the trash
+func (m *Memory) GetTaskByTaskID(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.liveTask(userID, taskID)
+	if !ok {
+		return nil, errorset.ErrTaskNotFound
+	}
+
+	return copyTask(t), nil
+}
+
+// UpdateTaskContent changes the content of a task owned by userID if it is still at version
+// and returns its new version
+func (m *Memory) UpdateTaskContent(ctx context.Context, userID, taskID int64, content string, version int64) (int64, error) {
+	updated, err := m.updateTask(userID, taskID, version, func(t *task.Task) {
+		t.TaskContent = content
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// UpdateTaskStatus sets the status of a task if it is still at version and returns the task;
+// completed_at follows the done status
+func (m *Memory) UpdateTaskStatus(ctx context.Context, userID, taskID int64, status string, version int64) (*task.Task, error) {
+	return m.updateTask(userID, taskID, version, func(t *task.Task) {
+		setStatus(t, status)
+	})
+}
+
+// setStatus sets the status in an update, after updated_at, which completed_at takes on
+func setStatus(t *task.Task, status string) {
+	t.Status = status
+	if status != task.StatusDone {
+		t.CompletedAt = nil
+	} else if t.CompletedAt == nil {
+		t.CompletedAt = copyTime(&t.UpdatedAt)
+	}
+}
+
+// ToggleTaskCompletion marks an open task as done and a done task as todo if it is still at version
+func (m *Memory) ToggleTaskCompletion(ctx context.Context, userID, taskID int64, version int64) (*task.Task, error) {
+	return m.updateTask(userID, taskID, version, func(t *task.Task) {
+		if t.Status == task.StatusDone {
+			setStatus(t, task.StatusTodo)
+		} else {
+			setStatus(t, task.StatusDone)
+		}
+	})
+}
+
+// UpdateTaskDueAt sets or, with a nil dueAt, clears the due date of a task if it is still at version
+// and returns its new version
+func (m *Memory) UpdateTaskDueAt(ctx context.Context, userID, taskID int64, dueAt *time.Time, version int64) (int64, error) {
+	updated, err := m.updateTask(userID, taskID, version, func(t *task.Task) {
+		t.DueAt = copyTime(dueAt)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// UpdateTaskPriority sets the priority of a task if it is still at version and returns its new version
+func (m *Memory) UpdateTaskPriority(ctx context.Context, userID, taskID int64, priority int, version int64) (int64, error) {
+	updated, err := m.updateTask(userID, taskID, version, func(t *task.Task) {
+		t.Priority = priority
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// PatchTask writes the fields set in patch to a task owned by userID if it is still at version
+// and returns the task. An empty patch writes nothing.
+func (m *Memory) PatchTask(ctx context.Context, userID, taskID int64, patch task.Patch, version int64) (*task.Task, error) {
+	if patch.IsEmpty() {
+		current, err := m.GetTaskByTaskID(ctx, userID, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if version != task.AnyVersion && current.Version != version {
+			return nil, errorset.ErrVersionMismatch
+		}
+		return current, nil
+	}
+
+	return m.updateTask(userID, taskID, version, func(t *task.Task) {
+		if patch.TaskContent != nil {
+			t.TaskContent = *patch.TaskContent
+		}
+		if patch.Status != nil {
+			setStatus(t, *patch.Status)
+		}
+		if patch.Priority != nil {
+			t.Priority = *patch.Priority
+		}
+		if patch.SetDueAt {
+			t.DueAt = copyTime(patch.DueAt)
+		}
+	})
+}
+
+// updateTask applies update to a task owned by userID at version, bumps updated_at and version
+// and returns the updated task
+func (m *Memory) updateTask(userID, taskID, version int64, update func(t *task.Task)) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.liveTask(userID, taskID)
+	if !ok {
+		return nil, errorset.ErrTaskNotFound
+	}
+	if version != task.AnyVersion && t.Version != version {
+		return nil, errorset.ErrVersionMismatch
+	}
+
+	t.UpdatedAt = time.Now()
+	update(t)
+	t.Version++
+
+	return copyTask(t), nil
+}
+
+// DeleteTask moves a task owned by userID to the trash if it is still at version
+func (m *Memory) DeleteTask(ctx context.Context, userID, taskID int64, version int64) error {
+	_, err := m.updateTask(userID, taskID, version, func(t *task.Task) {
+		now := time.Now()
+		t.DeletedAt = &now
+	})
+	return err
+}
+
+// GetTrashedTasks retrieves the tasks of a user in the trash, most recently deleted first
+func (m *Memory) GetTrashedTasks(ctx context.Context, userID int64) ([]*task.Task, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tasks := []*task.Task{}
+	for _, t := range m.tasks {
+		if t.UserID == userID && t.DeletedAt != nil {
+			tasks = append(tasks, copyTask(t))
+		}
+	}
+	slices.SortFunc(tasks, func(a, b *task.Task) int {
+		if c := b.DeletedAt.Compare(*a.DeletedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.TaskID, b.TaskID)
+	})
+
+	return tasks, nil
+}
+
+// RestoreTask takes a task owned by userID out of the trash
+func (m *Memory) RestoreTask(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok || t.UserID != userID || t.DeletedAt == nil {
+		return nil, errorset.ErrTaskNotFound
+	}
+
+	t.DeletedAt = nil
+	t.UpdatedAt = time.Now()
+	t.Version++
+
+	return copyTask(t), nil
+}
+
+// PurgeTask deletes a task owned by userID in the trash for good
+func (m *Memory) PurgeTask(ctx context.Context, userID, taskID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[taskID]
+	if !ok || t.UserID != userID || t.DeletedAt == nil {
+		return errorset.ErrTaskNotFound
+	}
+
+	delete(m.tasks, taskID)
+	return nil
+}
+
+// PurgeTrashedTasks deletes the tasks moved to the trash before the given time for good
+func (m *Memory) PurgeTrashedTasks(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var deleted int64
+	for taskID, t := range m.tasks {
+		if t.DeletedAt != nil && t.DeletedAt.Before(before) {
+			delete(m.tasks, taskID)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// liveTask returns the task owned by userID unless it is in the trash; the caller holds the lock
+func (m *Memory) liveTask(userID, taskID int64) (*task.Task, bool) {
+	t, ok := m.tasks[taskID]
+	if !ok || t.UserID != userID || t.DeletedAt != nil {
+		return nil, false
+	}
+
+	return t, true
+}
+
+func copyTask(t *task.Task) *task.Task {
+	c := *t
+	c.DueAt = copyTime(t.DueAt)
+	c.CompletedAt = copyTime(t.CompletedAt)
+	c.DeletedAt = copyTime(t.DeletedAt)
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	c := *t
+	return &c
+}
@@ -0,0 +1,193 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/token"
+)
+
+// SaveRefreshToken stores a new refresh token of an existing user
+func (m *Memory) SaveRefreshToken(ctx context.Context, refreshToken *token.RefreshToken) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[refreshToken.UserID]; !ok {
+		return 0, errorset.ErrUserNotFound
+	}
+
+	return m.saveRefreshToken(refreshToken.TokenHash, refreshToken.UserID, refreshToken.FamilyID, refreshToken.ExpiresAt)
+}
+
+// GetRefreshTokenByHash retrieves a refresh token by its hash
+func (m *Memory) GetRefreshTokenByHash(ctx context.Context, hash string) (*token.RefreshToken, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	refreshToken, ok := m.refreshTokens[hash]
+	if !ok {
+		return nil, errorset.ErrRefreshTokenNotFound
+	}
+
+	return copyRefreshToken(refreshToken), nil
+}
+
+// RotateRefreshToken revokes the token with oldHash and stores newHash in the same family.
+// Presenting a token that was already rotated or revoked revokes the whole family.
+func (m *Memory) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*token.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.refreshTokens[oldHash]
+	if !ok {
+		return nil, errorset.ErrRefreshTokenNotFound
+	}
+
+	if old.RevokedAt != nil {
+		m.revokeRefreshTokens(func(t *token.RefreshToken) bool { return t.FamilyID == old.FamilyID })
+		return nil, errorset.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(old.ExpiresAt) {
+		return nil, errorset.ErrRefreshTokenExpired
+	}
+
+	if _, err := m.saveRefreshToken(newHash, old.UserID, old.FamilyID, expiresAt); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	old.RevokedAt = &now
+
+	return copyRefreshToken(m.refreshTokens[newHash]), nil
+}
+
+// RevokeRefreshTokenFamily revokes every active refresh token of a family
+func (m *Memory) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.revokeRefreshTokens(func(t *token.RefreshToken) bool { return t.FamilyID == familyID })
+	return nil
+}
+
+// revokedToken is an access token on the denylist
+type revokedToken struct {
+	userID    int64
+	expiresAt time.Time
+}
+
+// RevokeAccessToken adds an access token to the denylist
+func (m *Memory) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return errorset.ErrUserNotFound
+	}
+
+	if _, ok := m.revokedTokens[jti]; !ok {
+		m.revokedTokens[jti] = revokedToken{userID: userID, expiresAt: expiresAt}
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked checks if an access token is on the denylist
+func (m *Memory) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, revoked := m.revokedTokens[jti]
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens removes the revoked access tokens that have expired anyway
+func (m *Memory) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	var deleted int64
+	for jti, revoked := range m.revokedTokens {
+		if !revoked.expiresAt.After(now) {
+			delete(m.revokedTokens, jti)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// GetTokensValidAfter retrieves the time before which the user's access tokens are rejected.
+// The zero time is returned if the user never revoked their tokens.
+func (m *Memory) GetTokensValidAfter(ctx context.Context, userID int64) (time.Time, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return time.Time{}, errorset.ErrUserNotFound
+	}
+
+	return m.tokensValidAfter[userID], nil
+}
+
+// RevokeUserTokens invalidates every access and refresh token issued to the user so far
+func (m *Memory) RevokeUserTokens(ctx context.Context, userID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[userID]; !ok {
+		return errorset.ErrUserNotFound
+	}
+
+	m.revokeUserTokens(userID)
+	return nil
+}
+
+// revokeUserTokens moves tokens_valid_after to now and revokes the refresh tokens of userID; m.mu must be held
+func (m *Memory) revokeUserTokens(userID int64) {
+	m.tokensValidAfter[userID] = time.Now()
+	m.revokeRefreshTokens(func(t *token.RefreshToken) bool { return t.UserID == userID })
+}
+
+// revokeRefreshTokens revokes the active refresh tokens selected by match; m.mu must be held
+func (m *Memory) revokeRefreshTokens(match func(t *token.RefreshToken) bool) {
+	now := time.Now()
+	for _, refreshToken := range m.refreshTokens {
+		if refreshToken.RevokedAt == nil && match(refreshToken) {
+			refreshToken.RevokedAt = &now
+		}
+	}
+}
+
+// saveRefreshToken stores a refresh token with a unique hash; m.mu must be held
+func (m *Memory) saveRefreshToken(hash string, userID int64, familyID string, expiresAt time.Time) (int64, error) {
+	if _, exists := m.refreshTokens[hash]; exists {
+		return 0, fmt.Errorf("refresh token hash already exists")
+	}
+
+	m.lastRefreshTokenID++
+	m.refreshTokens[hash] = &token.RefreshToken{
+		TokenID:   m.lastRefreshTokenID,
+		TokenHash: hash,
+		UserID:    userID,
+		FamilyID:  familyID,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	}
+
+	return m.lastRefreshTokenID, nil
+}
+
+func copyRefreshToken(t *token.RefreshToken) *token.RefreshToken {
+	c := *t
+	if t.RevokedAt != nil {
+		revokedAt := *t.RevokedAt
+		c.RevokedAt = &revokedAt
+	}
+	return &c
+}
@@ -0,0 +1,118 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/idempotency"
+)
+
+// SaveIdempotencyKey reserves an idempotency key in the PostgreSQL database while its first request runs.
+// An expired key is taken over, a live one is reported as errorset.ErrIdempotencyKeyExists.
+func (ps *PostgreSQL) SaveIdempotencyKey(ctx context.Context, record *idempotency.Record) error {
+	stmt, err := ps.db.PrepareContext(ctx, `
+		INSERT INTO idempotency_keys (scope, idempotency_key, request_hash, expires_at) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (scope, idempotency_key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			response_body = NULL,
+			created_at = CURRENT_TIMESTAMP,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, record.Scope, record.Key, record.RequestHash, record.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return affectingOne(result, errorset.ErrIdempotencyKeyExists)
+}
+
+// GetIdempotencyKey retrieves a live idempotency key from the PostgreSQL database
+func (ps *PostgreSQL) GetIdempotencyKey(ctx context.Context, scope, key string) (*idempotency.Record, error) {
+	stmt, err := ps.db.PrepareContext(ctx, `
+		SELECT scope, idempotency_key, request_hash, status_code, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2 AND expires_at > CURRENT_TIMESTAMP`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var record idempotency.Record
+	var statusCode sql.NullInt64
+	err = stmt.QueryRowContext(ctx, scope, key).Scan(
+		&record.Scope,
+		&record.Key,
+		&record.RequestHash,
+		&statusCode,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrIdempotencyKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	record.StatusCode = int(statusCode.Int64)
+
+	return &record, nil
+}
+
+// CompleteIdempotencyKey stores the response of the first request of an idempotency key in the PostgreSQL database
+func (ps *PostgreSQL) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, body []byte) error {
+	stmt, err := ps.db.PrepareContext(ctx, "UPDATE idempotency_keys SET status_code = $3, response_body = $4 WHERE scope = $1 AND idempotency_key = $2")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, scope, key, statusCode, body)
+	if err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return affectingOne(result, errorset.ErrIdempotencyKeyNotFound)
+}
+
+// DeleteIdempotencyKey releases an idempotency key in the PostgreSQL database, so the request can be retried
+func (ps *PostgreSQL) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	if _, err := ps.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2", scope, key); err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes the expired idempotency keys from the PostgreSQL database
+func (ps *PostgreSQL) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := ps.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= CURRENT_TIMESTAMP")
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// affectingOne returns notFound if the statement of result affected no row
+func affectingOne(result sql.Result, notFound error) error {
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
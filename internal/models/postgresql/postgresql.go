@@ -1,15 +1,14 @@
 package postgresql
 
 import (
+	"context"
 	"database/sql"
-	"errors"
 	"fmt"
 	"log"
 
 	"restapi/internal/config"
 	"restapi/internal/errorset"
 	"restapi/internal/lib/hashtool"
-	"restapi/internal/models/task"
 	"restapi/internal/models/user"
 	"restapi/internal/storage"
 
@@ -24,45 +23,58 @@ type PostgreSQL struct {
 
 // NewPostgreSQL creates a new PostgreSQL
 func NewPostgreSQL(cfg *config.Config) storage.Storage {
+	db, err := Open(cfg.Database)
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+
+	return &PostgreSQL{db: db, config: cfg}
+}
+
+// Open connects to the PostgreSQL database described by cfg
+func Open(cfg config.StorageConfig) (*sql.DB, error) {
 	connStr := fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		cfg.Database.Host,
-		cfg.Database.Port,
-		cfg.Database.User,
-		cfg.Database.Password,
-		cfg.Database.DatabaseName,
+		cfg.Host,
+		cfg.Port,
+		cfg.User,
+		cfg.Password,
+		cfg.DatabaseName,
 	)
 
 	db, err := sql.Open("postgres", connStr)
 	if err != nil {
-		log.Fatalf("failed to open database: %v", err)
+		return nil, err
 	}
 
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+
 	if err := db.Ping(); err != nil {
-		log.Fatalf("failed to ping database: %v", err)
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &PostgreSQL{db: db, config: cfg}
+	return db, nil
 }
 
 // SaveUser inserts a new user record into the PostgreSQL database
-func (ps *PostgreSQL) SaveUser(username, password string) (int64, error) {
+func (ps *PostgreSQL) SaveUser(ctx context.Context, username, password string) (int64, error) {
 	hashedPassword, err := hashtool.BcryptHashing(password)
 	if err != nil {
 		return 0, fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	stmt, err := ps.db.Prepare("INSERT INTO users (username, password) VALUES ($1, $2) RETURNING user_id")
+	stmt, err := ps.db.PrepareContext(ctx, "INSERT INTO users (username, password) VALUES ($1, $2) RETURNING user_id")
 	if err != nil {
 		return 0, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
 	var userID int64
-	err = stmt.QueryRow(username, hashedPassword).Scan(&userID)
+	err = stmt.QueryRowContext(ctx, username, hashedPassword).Scan(&userID)
 	if err != nil {
 		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
-			return 0, fmt.Errorf("username already exists")
+			return 0, errorset.ErrDuplicateUser
 		}
 		return 0, fmt.Errorf("failed to execute statement: %w", err)
 	}
@@ -71,15 +83,35 @@ func (ps *PostgreSQL) SaveUser(username, password string) (int64, error) {
 }
 
 // GetUserByID retrieves a record from the PostgreSQL database by key
-func (ps *PostgreSQL) GetUserByID(id int64) (*user.User, error) {
-	stmt, err := ps.db.Prepare("SELECT user_id, username, password, created_at FROM users WHERE user_id = $1")
+func (ps *PostgreSQL) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "SELECT user_id, username, password, role, disabled_at, created_at FROM users WHERE user_id = $1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var user user.User
+	err = stmt.QueryRowContext(ctx, id).Scan(&user.UserID, &user.UserName, &user.Password, &user.Role, &user.DisabledAt, &user.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetUserByUsername retrieves a record from the PostgreSQL database by username
+func (ps *PostgreSQL) GetUserByUsername(ctx context.Context, username string) (*user.User, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "SELECT user_id, username, password, role, disabled_at, created_at FROM users WHERE username = $1")
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
 	var user user.User
-	err = stmt.QueryRow(id).Scan(&user.UserID, &user.UserName, &user.Password, &user.CreatedAt)
+	err = stmt.QueryRowContext(ctx, username).Scan(&user.UserID, &user.UserName, &user.Password, &user.Role, &user.DisabledAt, &user.CreatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, errorset.ErrUserNotFound
@@ -91,15 +123,15 @@ func (ps *PostgreSQL) GetUserByID(id int64) (*user.User, error) {
 }
 
 // UsernameExists checks if a record with the given username exists in the PostgreSQL database
-func (ps *PostgreSQL) UsernameExists(name string) (bool, error) {
-	stmt, err := ps.db.Prepare("SELECT 1 FROM users WHERE username = $1")
+func (ps *PostgreSQL) UsernameExists(ctx context.Context, name string) (bool, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "SELECT 1 FROM users WHERE username = $1")
 	if err != nil {
 		return false, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
 	var exists bool
-	err = stmt.QueryRow(name).Scan(&exists)
+	err = stmt.QueryRowContext(ctx, name).Scan(&exists)
 	if err == sql.ErrNoRows {
 		return false, nil
 	} else if err != nil {
@@ -109,20 +141,47 @@ func (ps *PostgreSQL) UsernameExists(name string) (bool, error) {
 	return exists, nil
 }
 
-// UpdateUser updates a record in the PostgreSQL database
-func (ps *PostgreSQL) UpdateUserPassword(id int64, password string) error {
-	stmt, err := ps.db.Prepare("UPDATE users SET password = $1 WHERE user_id = $2")
+// UpdateUsername renames a user in the PostgreSQL database
+func (ps *PostgreSQL) UpdateUsername(ctx context.Context, id int64, username string) error {
+	stmt, err := ps.db.PrepareContext(ctx, "UPDATE users SET username = $1 WHERE user_id = $2")
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
+	result, err := stmt.ExecContext(ctx, username, id)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return errorset.ErrDuplicateUser
+		}
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return errorset.ErrUserNotFound
+	}
+
+	return nil
+}
+
+// UpdateUser updates a record in the PostgreSQL database.
+// Changing the password also invalidates every token issued to the user.
+func (ps *PostgreSQL) UpdateUserPassword(ctx context.Context, id int64, password string) error {
 	var hashedPassword string
+	var err error
 	if hashedPassword, err = hashtool.BcryptHashing(password); err != nil {
 		return fmt.Errorf("failed to hash password: %w", err)
 	}
 
-	result, err := stmt.Exec(hashedPassword, id)
+	tx, err := ps.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE users SET password = $1, tokens_valid_after = CURRENT_TIMESTAMP WHERE user_id = $2", hashedPassword, id)
 	if err != nil {
 		return fmt.Errorf("failed to execute statement: %w", err)
 	}
@@ -136,18 +195,26 @@ func (ps *PostgreSQL) UpdateUserPassword(id int64, password string) error {
 		return errorset.ErrUserNotFound
 	}
 
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL", id); err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
 // DeleteUser deletes a record from the PostgreSQL database
-func (ps *PostgreSQL) DeleteUser(id int64) error {
-	stmt, err := ps.db.Prepare("DELETE FROM users WHERE user_id = $1")
+func (ps *PostgreSQL) DeleteUser(ctx context.Context, id int64) error {
+	stmt, err := ps.db.PrepareContext(ctx, "DELETE FROM users WHERE user_id = $1")
 	if err != nil {
 		return fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	result, err := stmt.Exec(id)
+	result, err := stmt.ExecContext(ctx, id)
 	if err != nil {
 		return fmt.Errorf("failed to execute statement: %w", err)
 	}
@@ -161,116 +228,132 @@ func (ps *PostgreSQL) DeleteUser(id int64) error {
 	return nil
 }
 
-// SaveTask inserts a new task record into the PostgreSQL database
-func (ps *PostgreSQL) SaveTask(userId int64, content string) (int64, error) {
-	stmt, err := ps.db.Prepare("INSERT INTO tasks (user_id, task_content) VALUES ($1, $2) RETURNING task_id")
+// GetUsers retrieves every user record from the PostgreSQL database
+func (ps *PostgreSQL) GetUsers(ctx context.Context) ([]*user.User, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "SELECT user_id, username, role, disabled_at, created_at FROM users ORDER BY user_id")
 	if err != nil {
-		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
 	}
 	defer stmt.Close()
 
-	var taskID int64
-	err = stmt.QueryRow(userId, content).Scan(&taskID)
+	rows, err := stmt.QueryContext(ctx)
 	if err != nil {
-		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == errorset.ErrForeignKeyConstraintViolation {
-			return 0, errorset.ErrUserNotFound
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*user.User{}
+	for rows.Next() {
+		var user user.User
+		if err := rows.Scan(&user.UserID, &user.UserName, &user.Role, &user.DisabledAt, &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 
-		return 0, fmt.Errorf("failed to execute statement: %w", err)
+		users = append(users, &user)
 	}
 
-	return taskID, nil
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return users, nil
 }
 
-// GetTasksByUserID retrieves a record from the PostgreSQL database by key
-func (ps *PostgreSQL) GetTasksByUserID(userID int64) ([]*task.Task, error) {
-	if _, err := ps.GetUserByID(userID); err != nil {
-		if errors.Is(err, errorset.ErrUserNotFound) {
-			return nil, errorset.ErrUserNotFound
+// SetUserDisabled disables or enables a user in the PostgreSQL database.
+// Disabling also invalidates every token issued to the user.
+func (ps *PostgreSQL) SetUserDisabled(ctx context.Context, id int64, disabled bool) error {
+	if !disabled {
+		stmt, err := ps.db.PrepareContext(ctx, "UPDATE users SET disabled_at = NULL WHERE user_id = $1")
+		if err != nil {
+			return fmt.Errorf("failed to prepare statement: %w", err)
 		}
+		defer stmt.Close()
 
-		return nil, err
+		result, err := stmt.ExecContext(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to execute statement: %w", err)
+		}
+
+		if rowsAffected, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to retrieve rows affected: %w", err)
+		} else if rowsAffected == 0 {
+			return errorset.ErrUserNotFound
+		}
+
+		return nil
 	}
 
-	stmt, err := ps.db.Prepare("SELECT task_id, user_id, task_content, created_at FROM tasks WHERE user_id = $1")
+	tx, err := ps.db.BeginTx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
-	defer stmt.Close()
+	defer tx.Rollback()
 
-	rows, err := stmt.Query(userID)
+	result, err := tx.ExecContext(ctx, "UPDATE users SET disabled_at = COALESCE(disabled_at, CURRENT_TIMESTAMP), tokens_valid_after = CURRENT_TIMESTAMP WHERE user_id = $1", id)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute statement: %w", err)
+		return fmt.Errorf("failed to execute statement: %w", err)
 	}
 
-	var tasks []*task.Task
-	for rows.Next() {
-		var task task.Task
-		err = rows.Scan(&task.TaskID, &task.UserID, &task.TaskContent, &task.CreatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
-
-		tasks = append(tasks, &task)
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return errorset.ErrUserNotFound
 	}
 
-	return tasks, nil
-}
-
-// GetTaskByTaskID retrieves a record from the PostgreSQL database by key
-func (ps *PostgreSQL) GetTaskByTaskID(taskID int64) (*task.Task, error) {
-	stmt, err := ps.db.Prepare("SELECT task_id, user_id, task_content, created_at FROM tasks WHERE task_id = $1")
-	if err != nil {
-		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL", id); err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
 	}
-	defer stmt.Close()
 
-	var task task.Task
-	err = stmt.QueryRow(taskID).Scan(&task.TaskID, &task.UserID, &task.TaskContent, &task.CreatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errorset.ErrTaskNotFound
-		}
-		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	return &task, nil
+	return nil
 }
 
-// UpdateTask updates a record in the PostgreSQL database
-func (ps *PostgreSQL) UpdateTaskContent(task_id int64, content string) error {
-	stmt, err := ps.db.Prepare("UPDATE tasks SET task_content = $1 WHERE task_id = $2")
+// SetUserRole changes the role of a user in the PostgreSQL database.
+// Tokens issued so far carry the old role, so they are invalidated.
+func (ps *PostgreSQL) SetUserRole(ctx context.Context, id int64, role string) error {
+	tx, err := ps.db.BeginTx(ctx, nil)
 	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	_, err = stmt.Exec(content, task_id)
+	result, err := tx.ExecContext(ctx, "UPDATE users SET role = $1, tokens_valid_after = CURRENT_TIMESTAMP WHERE user_id = $2", role, id)
 	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == errorset.ErrForeignKeyConstraintViolation {
+			return errorset.ErrRoleNotFound
+		}
+
 		return fmt.Errorf("failed to execute statement: %w", err)
 	}
 
-	return nil
-}
-
-// DeleteTask deletes a record from the PostgreSQL database
-func (ps *PostgreSQL) DeleteTask(task_id int64) error {
-	stmt, err := ps.db.Prepare("DELETE FROM tasks WHERE task_id = $1")
-	if err != nil {
-		return fmt.Errorf("failed to prepare statement: %w", err)
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return errorset.ErrUserNotFound
 	}
-	defer stmt.Close()
 
-	_, err = stmt.Exec(task_id)
-	if err != nil {
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND revoked_at IS NULL", id); err != nil {
 		return fmt.Errorf("failed to execute statement: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
 // Ping checks the connection to the PostgreSQL database
-func (ps *PostgreSQL) Ping() error {
-	return ps.db.Ping()
+func (ps *PostgreSQL) Ping(ctx context.Context) error {
+	return ps.db.PingContext(ctx)
+}
+
+// Stats returns the connection pool statistics of the PostgreSQL database
+func (ps *PostgreSQL) Stats() sql.DBStats {
+	return ps.db.Stats()
 }
 
 // Close closes the connection to the PostgreSQL database
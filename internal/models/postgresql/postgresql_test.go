@@ -0,0 +1,42 @@
+package postgresql
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"restapi/internal/storage"
+	"restapi/internal/storage/storagetest"
+)
+
+// postgresTestDSNEnvKey names a migrated, disposable database; the tests truncate every table
+const postgresTestDSNEnvKey = "POSTGRES_TEST_DSN"
+
+func TestConformance(t *testing.T) {
+	dsn := os.Getenv(postgresTestDSNEnvKey)
+	if dsn == "" {
+		t.Skipf("%s is not set up", postgresTestDSNEnvKey)
+	}
+
+	reset, err := os.ReadFile("../../../migration/reset.sql")
+	if err != nil {
+		t.Fatalf("failed to read reset script: %v", err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		if _, err := db.Exec(string(reset)); err != nil {
+			t.Fatalf("failed to reset database: %v", err)
+		}
+		if _, err := db.Exec("DELETE FROM roles WHERE role_name NOT IN ('user', 'admin')"); err != nil {
+			t.Fatalf("failed to reset roles: %v", err)
+		}
+
+		return &PostgreSQL{db: db}
+	})
+}
@@ -0,0 +1,78 @@
+package postgresql
+
+import (
+	"context"
+	"fmt"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/audit"
+
+	"github.com/lib/pq"
+)
+
+// GetRoles retrieves every role name from the PostgreSQL database
+func (ps *PostgreSQL) GetRoles(ctx context.Context) ([]string, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "SELECT role_name FROM roles ORDER BY role_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+// SaveRole inserts a new role into the PostgreSQL database
+func (ps *PostgreSQL) SaveRole(ctx context.Context, role string) error {
+	stmt, err := ps.db.PrepareContext(ctx, "INSERT INTO roles (role_name) VALUES ($1)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, role); err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" {
+			return errorset.ErrDuplicateRole
+		}
+
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return nil
+}
+
+// SaveAuditLog inserts a new audit log entry into the PostgreSQL database
+func (ps *PostgreSQL) SaveAuditLog(ctx context.Context, entry *audit.Entry) (int64, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "INSERT INTO audit_logs (actor_id, action, target_user_id, target_task_id, details) VALUES ($1, $2, $3, $4, $5) RETURNING audit_log_id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var auditLogID int64
+	err = stmt.QueryRowContext(ctx, entry.ActorID, entry.Action, entry.TargetUserID, entry.TargetTaskID, entry.Details).Scan(&auditLogID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return auditLogID, nil
+}
@@ -0,0 +1,473 @@
+package postgresql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/task"
+
+	"github.com/lib/pq"
+)
+
+// taskColumns is the column list scanTask expects
+const taskColumns = "task_id, user_id, task_content, status, priority, due_at, completed_at, created_at, updated_at, version, deleted_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask scans the taskColumns of row, followed by any extra columns
+func scanTask(row rowScanner, extra ...any) (*task.Task, error) {
+	var task task.Task
+	dest := []any{
+		&task.TaskID,
+		&task.UserID,
+		&task.TaskContent,
+		&task.Status,
+		&task.Priority,
+		&task.DueAt,
+		&task.CompletedAt,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.Version,
+		&task.DeletedAt,
+	}
+
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// SaveTask inserts a new task record into the PostgreSQL database
+func (ps *PostgreSQL) SaveTask(ctx context.Context, newTask *task.Task) (int64, error) {
+	stmt, err := ps.db.PrepareContext(ctx, `INSERT INTO tasks (user_id, task_content, status, priority, due_at, completed_at)
+		VALUES ($1, $2, $3, $4, $5, CASE WHEN $6 = 'done' THEN CURRENT_TIMESTAMP END) RETURNING task_id`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	status := newTask.Status
+	if status == "" {
+		status = task.StatusTodo
+	}
+
+	var taskID int64
+	err = stmt.QueryRowContext(ctx, newTask.UserID, newTask.TaskContent, status, newTask.Priority, newTask.DueAt, status).Scan(&taskID)
+	if err != nil {
+		if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == errorset.ErrForeignKeyConstraintViolation {
+			return 0, errorset.ErrUserNotFound
+		}
+
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// sortColumns maps task sort fields to their SQL expression and the type of their cursor values.
+// Tasks without due date sort after every date.
+var sortColumns = map[string]struct{ expr, cast string }{
+	task.SortCreatedAt: {"created_at", "timestamptz"},
+	task.SortUpdatedAt: {"updated_at", "timestamptz"},
+	task.SortDueAt:     {"COALESCE(due_at, 'infinity'::timestamptz)", "timestamptz"},
+	task.SortPriority:  {"priority", "integer"},
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// GetTasksByUserID retrieves one page of the tasks of a user matching the query
+func (ps *PostgreSQL) GetTasksByUserID(ctx context.Context, userID int64, query task.Query) (*task.Page, error) {
+	if _, err := ps.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, errorset.ErrUserNotFound) {
+			return nil, errorset.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	args := []any{userID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	where := []string{"user_id = $1", "deleted_at IS NULL"}
+	if len(query.Statuses) > 0 {
+		where = append(where, "status = ANY("+arg(pq.Array(query.Statuses))+")")
+	}
+	if len(query.Priorities) > 0 {
+		priorities := make([]int64, 0, len(query.Priorities))
+		for _, priority := range query.Priorities {
+			priorities = append(priorities, int64(priority))
+		}
+		where = append(where, "priority = ANY("+arg(pq.Array(priorities))+")")
+	}
+	if query.DueAfter != nil {
+		where = append(where, "due_at >= "+arg(*query.DueAfter))
+	}
+	if query.DueBefore != nil {
+		where = append(where, "due_at < "+arg(*query.DueBefore))
+	}
+	if query.CreatedSince != nil {
+		where = append(where, "created_at >= "+arg(*query.CreatedSince))
+	}
+	if query.Text != "" {
+		where = append(where, "task_content ILIKE "+arg("%"+likeEscaper.Replace(query.Text)+"%"))
+	}
+
+	orderBy := make([]string, 0, len(query.Sort)+1)
+	for _, key := range query.Sort {
+		direction := " ASC"
+		if key.Desc {
+			direction = " DESC"
+		}
+		orderBy = append(orderBy, sortColumns[key.Field].expr+direction)
+	}
+	orderBy = append(orderBy, "task_id ASC")
+
+	// keyset pagination: (k1 after v1) OR (k1 = v1 AND k2 after v2) OR ... OR (all equal AND task_id > id)
+	if query.Cursor != nil {
+		var keyset []string
+		var equal []string
+
+		for i, key := range query.Sort {
+			column := sortColumns[key.Field]
+			value := arg(query.Cursor.Values[i]) + "::" + column.cast
+
+			operator := " > "
+			if key.Desc {
+				operator = " < "
+			}
+
+			keyset = append(keyset, "("+strings.Join(append(slices.Clone(equal), column.expr+operator+value), " AND ")+")")
+			equal = append(equal, column.expr+" = "+value)
+		}
+
+		keyset = append(keyset, "("+strings.Join(append(equal, "task_id > "+arg(query.Cursor.TaskID)), " AND ")+")")
+		where = append(where, "("+strings.Join(keyset, " OR ")+")")
+	}
+
+	statement := "SELECT " + taskColumns + " FROM tasks WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY " + strings.Join(orderBy, ", ") +
+		" LIMIT " + arg(query.Limit+1)
+
+	rows, err := ps.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	page := &task.Page{Tasks: []*task.Task{}}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		page.Tasks = append(page.Tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	// one row more than the limit was fetched to know whether another page exists
+	if len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.NextCursor = task.EncodeCursor(page.Tasks[query.Limit-1], query.Sort)
+	}
+
+	return page, nil
+}
+
+// escapedContent is task_content escaped like html.EscapeString, for snippets shown as HTML.
+// The simple configuration doesn't index the entities, so they are never highlighted.
+const escapedContent = `replace(replace(replace(replace(replace(task_content,
+	'&', '&amp;'), '<', '&lt;'), '>', '&gt;'), '"', '&#34;'), '''', '&#39;')`
+
+// SearchTasks ranks the tasks of a user matching every search term as a word prefix
+// using the full-text index of the PostgreSQL database
+func (ps *PostgreSQL) SearchTasks(ctx context.Context, userID int64, query task.SearchQuery) ([]*task.SearchResult, error) {
+	if _, err := ps.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, errorset.ErrUserNotFound) {
+			return nil, errorset.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	// search terms only hold letters and digits, so they can't inject tsquery operators
+	prefixes := make([]string, 0, len(query.Terms))
+	for _, term := range query.Terms {
+		prefixes = append(prefixes, term+":*")
+	}
+
+	stmt, err := ps.db.PrepareContext(ctx, `SELECT `+taskColumns+`, ts_rank(search_vector, query) AS rank,
+			ts_headline('simple', `+escapedContent+`, query, $3)
+		FROM tasks, to_tsquery('simple', $2) AS query
+		WHERE user_id = $1 AND deleted_at IS NULL AND search_vector @@ query
+		ORDER BY rank DESC, task_id ASC
+		LIMIT $4`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	options := fmt.Sprintf("StartSel=%s, StopSel=%s, MaxWords=35, MinWords=15", task.HighlightStart, task.HighlightStop)
+
+	rows, err := stmt.QueryContext(ctx, userID, strings.Join(prefixes, " & "), options, query.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	results := []*task.SearchResult{}
+	for rows.Next() {
+		var result task.SearchResult
+		result.Task, err = scanTask(rows, &result.Rank, &result.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		results = append(results, &result)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return results, nil
+}
+
+// GetTaskByTaskID retrieves a record owned by userID and not in the trash from the PostgreSQL database by key
+func (ps *PostgreSQL) GetTaskByTaskID(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	task, err := scanTask(stmt.QueryRowContext(ctx, taskID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return task, nil
+}
+
+// UpdateTask updates a record owned by userID in the PostgreSQL database if it is still at version
+// and returns its new version
+func (ps *PostgreSQL) UpdateTaskContent(ctx context.Context, userID, task_id int64, content string, version int64) (int64, error) {
+	updated, err := ps.updateTask(ctx, "task_content = $4", userID, task_id, version, content)
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// UpdateTaskStatus sets the status of a task if it is still at version and returns the task;
+// completed_at follows the done status
+func (ps *PostgreSQL) UpdateTaskStatus(ctx context.Context, userID, taskID int64, status string, version int64) (*task.Task, error) {
+	return ps.updateTask(ctx,
+		"status = $4, completed_at = CASE WHEN $5 = 'done' THEN COALESCE(completed_at, CURRENT_TIMESTAMP) END",
+		userID, taskID, version, status, status,
+	)
+}
+
+// ToggleTaskCompletion marks an open task as done and a done task as todo if it is still at version
+func (ps *PostgreSQL) ToggleTaskCompletion(ctx context.Context, userID, taskID int64, version int64) (*task.Task, error) {
+	return ps.updateTask(ctx, `status = CASE WHEN status = 'done' THEN 'todo' ELSE 'done' END,
+			completed_at = CASE WHEN status = 'done' THEN NULL ELSE CURRENT_TIMESTAMP END`,
+		userID, taskID, version,
+	)
+}
+
+// UpdateTaskDueAt sets or, with a nil dueAt, clears the due date of a task if it is still at version
+// and returns its new version
+func (ps *PostgreSQL) UpdateTaskDueAt(ctx context.Context, userID, taskID int64, dueAt *time.Time, version int64) (int64, error) {
+	updated, err := ps.updateTask(ctx, "due_at = $4", userID, taskID, version, dueAt)
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// UpdateTaskPriority sets the priority of a task if it is still at version and returns its new version
+func (ps *PostgreSQL) UpdateTaskPriority(ctx context.Context, userID, taskID int64, priority int, version int64) (int64, error) {
+	updated, err := ps.updateTask(ctx, "priority = $4", userID, taskID, version, priority)
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// PatchTask writes the fields set in patch to a task owned by userID if it is still at version
+// and returns the task. An empty patch writes nothing.
+func (ps *PostgreSQL) PatchTask(ctx context.Context, userID, taskID int64, patch task.Patch, version int64) (*task.Task, error) {
+	if patch.IsEmpty() {
+		current, err := ps.GetTaskByTaskID(ctx, userID, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if version != task.AnyVersion && current.Version != version {
+			return nil, errorset.ErrVersionMismatch
+		}
+		return current, nil
+	}
+
+	// $1 to $3 are taken by updateTask
+	var values []any
+	arg := func(value any) string {
+		values = append(values, value)
+		return "$" + strconv.Itoa(len(values)+3)
+	}
+
+	var set []string
+	if patch.TaskContent != nil {
+		set = append(set, "task_content = "+arg(*patch.TaskContent))
+	}
+	if patch.Status != nil {
+		set = append(set, "status = "+arg(*patch.Status),
+			"completed_at = CASE WHEN "+arg(*patch.Status)+" = 'done' THEN COALESCE(completed_at, CURRENT_TIMESTAMP) END")
+	}
+	if patch.Priority != nil {
+		set = append(set, "priority = "+arg(*patch.Priority))
+	}
+	if patch.SetDueAt {
+		set = append(set, "due_at = "+arg(patch.DueAt))
+	}
+
+	return ps.updateTask(ctx, strings.Join(set, ", "), userID, taskID, version, values...)
+}
+
+// updateTask applies set, which refers to values as $4, $5..., to a task owned by userID at version,
+// bumps updated_at and version and returns the updated task
+func (ps *PostgreSQL) updateTask(ctx context.Context, set string, userID, taskID, version int64, values ...any) (*task.Task, error) {
+	stmt, err := ps.db.PrepareContext(ctx, "UPDATE tasks SET "+set+", updated_at = CURRENT_TIMESTAMP, version = version + 1"+
+		" WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NULL AND ($3::bigint = 0 OR version = $3) RETURNING "+taskColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	updated, err := scanTask(stmt.QueryRowContext(ctx, append([]any{taskID, userID, version}, values...)...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ps.missedTask(ctx, userID, taskID)
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteTask moves a record owned by userID in the PostgreSQL database to the trash if it is still at version
+func (ps *PostgreSQL) DeleteTask(ctx context.Context, userID, task_id int64, version int64) error {
+	_, err := ps.updateTask(ctx, "deleted_at = CURRENT_TIMESTAMP", userID, task_id, version)
+	return err
+}
+
+// GetTrashedTasks retrieves the tasks of a user in the trash, most recently deleted first
+func (ps *PostgreSQL) GetTrashedTasks(ctx context.Context, userID int64) ([]*task.Task, error) {
+	rows, err := ps.db.QueryContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE user_id = $1 AND deleted_at IS NOT NULL"+
+		" ORDER BY deleted_at DESC, task_id ASC", userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []*task.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// RestoreTask takes a task owned by userID out of the trash
+func (ps *PostgreSQL) RestoreTask(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	stmt, err := ps.db.PrepareContext(ctx, `UPDATE tasks SET deleted_at = NULL, updated_at = CURRENT_TIMESTAMP, version = version + 1
+		WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NOT NULL
+		RETURNING `+taskColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	task, err := scanTask(stmt.QueryRowContext(ctx, taskID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return task, nil
+}
+
+// PurgeTask deletes a task owned by userID in the trash from the PostgreSQL database for good
+func (ps *PostgreSQL) PurgeTask(ctx context.Context, userID, taskID int64) error {
+	result, err := ps.db.ExecContext(ctx, "DELETE FROM tasks WHERE task_id = $1 AND user_id = $2 AND deleted_at IS NOT NULL", taskID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return errorset.ErrTaskNotFound
+	}
+
+	return nil
+}
+
+// PurgeTrashedTasks deletes the tasks moved to the trash before the given time for good
+func (ps *PostgreSQL) PurgeTrashedTasks(ctx context.Context, before time.Time) (int64, error) {
+	result, err := ps.db.ExecContext(ctx, "DELETE FROM tasks WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// missedTask tells why a write to a task owned by userID at a version affected no row:
+// the task is gone or it has another version
+func (ps *PostgreSQL) missedTask(ctx context.Context, userID, taskID int64) error {
+	if _, err := ps.GetTaskByTaskID(ctx, userID, taskID); err != nil {
+		return err
+	}
+
+	return errorset.ErrVersionMismatch
+}
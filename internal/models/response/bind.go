@@ -0,0 +1,165 @@
+package response
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"restapi/internal/errorset"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func init() {
+	// name fields after their JSON keys in validation errors, the only names clients know
+	if engine, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		engine.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+
+	return name
+}
+
+// FieldErrors fails validation with fields checked outside of gin's binding
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for _, fieldErr := range e {
+		fields = append(fields, fieldErr.Field+" "+fieldErr.Detail)
+	}
+
+	return "invalid fields: " + strings.Join(fields, ", ")
+}
+
+// ReadOnlyField is a field a request may not change, and whether it did
+type ReadOnlyField struct {
+	Name    string
+	Changed bool
+}
+
+// ReadOnlyErrors fails validation for the read-only fields that changed, it is empty if none did
+func ReadOnlyErrors(fields ...ReadOnlyField) FieldErrors {
+	var fieldErrs FieldErrors
+	for _, field := range fields {
+		if field.Changed {
+			fieldErrs = append(fieldErrs, FieldError{Field: field.Name, Rule: "readonly", Detail: "is read-only"})
+		}
+	}
+
+	return fieldErrs
+}
+
+// unknownFieldPrefix starts the error of a JSON decoder that disallows unknown fields
+const unknownFieldPrefix = "json: unknown field "
+
+// bindProblem describes an error of gin's binding, with the invalid fields if there are any
+func bindProblem(err error) Problem {
+	var (
+		validationErrs validator.ValidationErrors
+		fieldErrs      FieldErrors
+		typeErr        *json.UnmarshalTypeError
+		syntaxErr      *json.SyntaxError
+		tooLargeErr    *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &validationErrs):
+		problem := NewProblem(errorset.ErrValidation)
+		problem.Detail = "request has invalid fields"
+		for _, fieldErr := range validationErrs {
+			problem.Errors = append(problem.Errors, FieldError{
+				Field:  fieldPath(fieldErr),
+				Rule:   fieldErr.Tag(),
+				Detail: ruleDetail(fieldErr),
+			})
+		}
+		return problem
+
+	case errors.As(err, &fieldErrs):
+		problem := NewProblem(errorset.ErrValidation)
+		problem.Detail = "request has invalid fields"
+		problem.Errors = fieldErrs
+		return problem
+
+	case strings.HasPrefix(err.Error(), unknownFieldPrefix):
+		field, _ := strconv.Unquote(strings.TrimPrefix(err.Error(), unknownFieldPrefix))
+		problem := NewProblem(errorset.ErrValidation)
+		problem.Detail = "request has invalid fields"
+		problem.Errors = []FieldError{{Field: field, Rule: "unknown", Detail: "is not a known field"}}
+		return problem
+
+	case errors.As(err, &typeErr):
+		problem := NewProblem(errorset.ErrValidation)
+		problem.Detail = "request has invalid fields"
+		problem.Errors = []FieldError{{
+			Field:  typeErr.Field,
+			Rule:   "type",
+			Detail: "must be " + typeErr.Type.Kind().String(),
+		}}
+		return problem
+
+	case errors.As(err, &tooLargeErr):
+		return NewProblem(fmt.Errorf("%w: limit is %d bytes", errorset.ErrBodyTooLarge, tooLargeErr.Limit))
+
+	case errors.As(err, &syntaxErr):
+		return NewProblem(fmt.Errorf("%w: %s", errorset.ErrMalformedBody, syntaxErr.Error()))
+
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return NewProblem(fmt.Errorf("%w: body is empty or truncated", errorset.ErrMalformedBody))
+	}
+
+	return NewProblem(fmt.Errorf("%w: %s", errorset.ErrMalformedBody, err.Error()))
+}
+
+// fieldPath is the namespace of the field without the name of the request struct
+func fieldPath(fieldErr validator.FieldError) string {
+	if _, path, ok := strings.Cut(fieldErr.Namespace(), "."); ok {
+		return path
+	}
+
+	return fieldErr.Field()
+}
+
+func ruleDetail(fieldErr validator.FieldError) string {
+	param := fieldErr.Param()
+
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		if fieldErr.Kind() == reflect.String {
+			return "must be at least " + param + " characters"
+		}
+		return "must be at least " + param
+	case "max":
+		if fieldErr.Kind() == reflect.String {
+			return "must be at most " + param + " characters"
+		}
+		return "must be at most " + param
+	case "oneof":
+		return "must be one of: " + strings.Join(strings.Fields(param), ", ")
+	case "alphanum":
+		return "must contain only letters and digits"
+	}
+
+	if param != "" {
+		return fmt.Sprintf("must satisfy %s=%s", fieldErr.Tag(), param)
+	}
+	return "must satisfy " + fieldErr.Tag()
+}
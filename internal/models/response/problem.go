@@ -0,0 +1,133 @@
+package response
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/task"
+)
+
+// ProblemContentType is the media type of error responses, see RFC 7807
+const ProblemContentType = "application/problem+json"
+
+// CodeInternal is the code of every error not in the registry
+const CodeInternal = "internal_error"
+
+// problemTypePrefix is joined with the code to build the type URI of a problem
+const problemTypePrefix = "/problems/"
+
+// Problem is the body of an error response
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+
+	RequestID string       `json:"requestId,omitempty"` // to quote when reporting the error
+	Errors    []FieldError `json:"errors,omitempty"`    // set when the request failed validation
+}
+
+// FieldError describes a field of the request that failed validation
+type FieldError struct {
+	Field  string `json:"field"`
+	Rule   string `json:"rule"`
+	Detail string `json:"detail"`
+}
+
+// Kind is the status and stable code every error matching Err is answered with
+type Kind struct {
+	Err    error
+	Status int
+	Code   string
+	Title  string
+}
+
+// registry maps errors to the problems they are answered with. The first kind whose Err
+// matches with errors.Is wins, so wrapped errors keep the kind of their sentinel.
+var registry = []Kind{
+	{errorset.ErrValidation, http.StatusBadRequest, "validation_failed", "Request validation failed"},
+	{errorset.ErrMalformedBody, http.StatusBadRequest, "malformed_body", "Malformed request body"},
+	{errorset.ErrInvalidPathParameter, http.StatusBadRequest, "invalid_path_parameter", "Invalid path parameter"},
+	{errorset.ErrInvalidPassword, http.StatusBadRequest, "invalid_password", "Invalid password"},
+	{errorset.ErrInvalidIdempotencyKey, http.StatusBadRequest, "invalid_idempotency_key", "Invalid idempotency key"},
+	{errorset.ErrDisableSelf, http.StatusBadRequest, "cannot_disable_self", "Cannot disable own account"},
+	{task.ErrInvalidQuery, http.StatusBadRequest, "invalid_query", "Invalid task query"},
+	{errorset.ErrInvalidPatch, http.StatusBadRequest, "invalid_patch", "Invalid patch document"},
+
+	{errorset.ErrAuthorizationMissing, http.StatusUnauthorized, "authorization_missing", "Authorization missing"},
+	{errorset.ErrInvalidAuthorization, http.StatusUnauthorized, "invalid_authorization", "Invalid authorization header"},
+	{errorset.ErrInvalidToken, http.StatusUnauthorized, "invalid_token", "Invalid token"},
+	{errorset.ErrInvalidTokenClaims, http.StatusUnauthorized, "invalid_token_claims", "Invalid token claims"},
+	{errorset.ErrTokenRevoked, http.StatusUnauthorized, "token_revoked", "Token revoked"},
+	{errorset.ErrInvalidCredentials, http.StatusUnauthorized, "invalid_credentials", "Invalid credentials"},
+	{errorset.ErrServiceUnauthorized, http.StatusUnauthorized, "service_unauthorized", "Service authentication failed"},
+	// the reason a refresh token is refused is not told, a stolen token must not learn it was reused
+	{errorset.ErrInvalidRefreshToken, http.StatusUnauthorized, "invalid_refresh_token", "Invalid refresh token"},
+	{errorset.ErrRefreshTokenNotFound, http.StatusUnauthorized, "invalid_refresh_token", "Invalid refresh token"},
+	{errorset.ErrRefreshTokenExpired, http.StatusUnauthorized, "invalid_refresh_token", "Invalid refresh token"},
+	{errorset.ErrRefreshTokenReused, http.StatusUnauthorized, "invalid_refresh_token", "Invalid refresh token"},
+
+	{errorset.ErrForbidden, http.StatusForbidden, "forbidden", "Forbidden"},
+	{errorset.ErrUserDisabled, http.StatusForbidden, "user_disabled", "User disabled"},
+
+	{errorset.ErrUserNotFound, http.StatusNotFound, "user_not_found", "User not found"},
+	{errorset.ErrTaskNotFound, http.StatusNotFound, "task_not_found", "Task not found"},
+	{errorset.ErrRoleNotFound, http.StatusNotFound, "role_not_found", "Role not found"},
+
+	{errorset.ErrDuplicateUser, http.StatusConflict, "duplicate_user", "Username already exists"},
+	{errorset.ErrDuplicateRole, http.StatusConflict, "duplicate_role", "Role already exists"},
+	{errorset.ErrIdempotencyKeyInProgress, http.StatusConflict, "idempotency_key_in_progress", "Request in progress"},
+	{errorset.ErrPatchTestFailed, http.StatusConflict, "patch_test_failed", "Patch test failed"},
+
+	{errorset.ErrVersionMismatch, http.StatusPreconditionFailed, "version_mismatch", "Precondition failed"},
+	{errorset.ErrBodyTooLarge, http.StatusRequestEntityTooLarge, "body_too_large", "Request body too large"},
+	{errorset.ErrUnsupportedMediaType, http.StatusUnsupportedMediaType, "unsupported_media_type", "Unsupported media type"},
+	{errorset.ErrIdempotencyKeyMismatch, http.StatusUnprocessableEntity, "idempotency_key_mismatch", "Idempotency key reused"},
+	{errorset.ErrPreconditionRequired, http.StatusPreconditionRequired, "precondition_required", "Precondition required"},
+	{errorset.ErrRateLimited, http.StatusTooManyRequests, "rate_limited", "Too many requests"},
+
+	{errorset.ErrShuttingDown, http.StatusServiceUnavailable, "shutting_down", "Shutting down"},
+	{errorset.ErrNotReady, http.StatusServiceUnavailable, "not_ready", "Not ready"},
+	{context.DeadlineExceeded, http.StatusGatewayTimeout, "timeout", "Request timed out"},
+}
+
+// internalKind answers every error not in the registry
+var internalKind = Kind{Status: http.StatusInternalServerError, Code: CodeInternal, Title: "Internal server error"}
+
+// KindOf returns the registered kind of err
+func KindOf(err error) Kind {
+	for _, kind := range registry {
+		if errors.Is(err, kind.Err) {
+			return kind
+		}
+	}
+
+	return internalKind
+}
+
+// Kinds lists the registered kinds, to document them
+func Kinds() []Kind {
+	return append([]Kind(nil), registry...)
+}
+
+// NewProblem describes err. The message of err is the detail of client errors only,
+// server errors may carry internals the client must not see.
+func NewProblem(err error) Problem {
+	kind := KindOf(err)
+
+	problem := Problem{
+		Type:   problemTypePrefix + kind.Code,
+		Title:  kind.Title,
+		Status: kind.Status,
+		Code:   kind.Code,
+	}
+	if kind.Status < http.StatusInternalServerError {
+		problem.Detail = err.Error()
+	}
+
+	return problem
+}
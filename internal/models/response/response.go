@@ -1,6 +1,7 @@
 package response
 
 import (
+	"restapi/internal/lib/requestid"
 	"restapi/internal/models/state"
 
 	"github.com/gin-gonic/gin"
@@ -18,8 +19,22 @@ func Ok(c *gin.Context, code int, data map[string]any) {
 	})
 }
 
-func Error(c *gin.Context, code int, errorMsg string) {
-	c.JSON(code, Response{
-		State: state.Error(errorMsg),
-	})
+// Error answers with the problem registered for err. Errors not in the registry
+// are answered with 500 Internal Server Error and without detail.
+func Error(c *gin.Context, err error) {
+	writeProblem(c, NewProblem(err))
+}
+
+// BindError answers a request gin failed to bind, listing the invalid fields
+func BindError(c *gin.Context, err error) {
+	writeProblem(c, bindProblem(err))
+}
+
+func writeProblem(c *gin.Context, problem Problem) {
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = requestid.FromContext(c.Request.Context())
+
+	// gin keeps a content type that is already set
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(problem.Status, problem)
 }
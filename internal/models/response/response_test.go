@@ -0,0 +1,160 @@
+package response
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"restapi/internal/errorset"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestNewProblem(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{
+			name:       "sentinel",
+			err:        errorset.ErrTaskNotFound,
+			wantStatus: http.StatusNotFound,
+			wantCode:   "task_not_found",
+			wantDetail: "task not found",
+		},
+		{
+			name:       "wrapped sentinel",
+			err:        fmt.Errorf("failed to get task: %w", errorset.ErrTaskNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "task_not_found",
+			wantDetail: "failed to get task: task not found",
+		},
+		{
+			name:       "timeout",
+			err:        fmt.Errorf("failed to get tasks: %w", context.DeadlineExceeded),
+			wantStatus: http.StatusGatewayTimeout,
+			wantCode:   "timeout",
+		},
+		{
+			name:       "unknown",
+			err:        errors.New("pq: relation \"tasks\" does not exist"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   CodeInternal,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			problem := NewProblem(tc.err)
+
+			if problem.Status != tc.wantStatus || problem.Code != tc.wantCode || problem.Detail != tc.wantDetail {
+				t.Fatalf("got %d %q %q, want %d %q %q", problem.Status, problem.Code, problem.Detail, tc.wantStatus, tc.wantCode, tc.wantDetail)
+			}
+			if problem.Type != "/problems/"+tc.wantCode {
+				t.Fatalf("type %q does not name code %q", problem.Type, tc.wantCode)
+			}
+		})
+	}
+}
+
+func TestRegistryCodes(t *testing.T) {
+	statuses := map[string]int{}
+	for _, kind := range Kinds() {
+		if kind.Code == "" || kind.Code != strings.ToLower(kind.Code) || strings.ContainsAny(kind.Code, " -") {
+			t.Errorf("code %q of %v is not snake case", kind.Code, kind.Err)
+		}
+		if status, ok := statuses[kind.Code]; ok && status != kind.Status {
+			t.Errorf("code %q is answered with %d and %d", kind.Code, status, kind.Status)
+		}
+		statuses[kind.Code] = kind.Status
+	}
+}
+
+func TestBindError(t *testing.T) {
+	type request struct {
+		TaskContent string `json:"taskContent" binding:"required"`
+		Status      string `json:"status" binding:"omitempty,oneof=todo done"`
+		Priority    int    `json:"priority" binding:"min=0,max=3"`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantFields []FieldError
+	}{
+		{
+			name:       "invalid fields",
+			body:       `{"status":"later","priority":7}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+			wantFields: []FieldError{
+				{Field: "taskContent", Rule: "required", Detail: "is required"},
+				{Field: "status", Rule: "oneof", Detail: "must be one of: todo, done"},
+				{Field: "priority", Rule: "max", Detail: "must be at most 3"},
+			},
+		},
+		{
+			name:       "wrong type",
+			body:       `{"taskContent":"a","priority":"high"}`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "validation_failed",
+			wantFields: []FieldError{{Field: "priority", Rule: "type", Detail: "must be int"}},
+		},
+		{
+			name:       "malformed",
+			body:       `{"taskContent":`,
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "malformed_body",
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/tasks", func(c *gin.Context) {
+		var req request
+		if err := c.ShouldBindJSON(&req); err != nil {
+			BindError(c, err)
+		}
+	})
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/tasks", strings.NewReader(tc.body)))
+
+			if got := rec.Header().Get("Content-Type"); got != ProblemContentType {
+				t.Fatalf("content type %q, want %q", got, ProblemContentType)
+			}
+
+			var problem Problem
+			if err := json.Unmarshal(rec.Body.Bytes(), &problem); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+
+			if rec.Code != tc.wantStatus || problem.Status != tc.wantStatus || problem.Code != tc.wantCode {
+				t.Fatalf("got %d/%d %q, want %d %q", rec.Code, problem.Status, problem.Code, tc.wantStatus, tc.wantCode)
+			}
+			if problem.Instance != "/tasks" {
+				t.Fatalf("instance %q, want the request path", problem.Instance)
+			}
+			if len(problem.Errors) != len(tc.wantFields) {
+				t.Fatalf("got field errors %+v, want %+v", problem.Errors, tc.wantFields)
+			}
+			for i, want := range tc.wantFields {
+				if problem.Errors[i] != want {
+					t.Errorf("field error %d: got %+v, want %+v", i, problem.Errors[i], want)
+				}
+			}
+		})
+	}
+}
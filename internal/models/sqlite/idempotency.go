@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/idempotency"
+)
+
+// SaveIdempotencyKey reserves an idempotency key in the SQLite database while its first request runs.
+// An expired key is taken over, a live one is reported as errorset.ErrIdempotencyKeyExists.
+func (s *SQLite) SaveIdempotencyKey(ctx context.Context, record *idempotency.Record) error {
+	return s.execAffectingOne(ctx, `
+		INSERT INTO idempotency_keys (scope, idempotency_key, request_hash, created_at, expires_at) VALUES (?1, ?2, ?3, ?4, ?5)
+		ON CONFLICT (scope, idempotency_key) DO UPDATE SET
+			request_hash = excluded.request_hash,
+			status_code = NULL,
+			response_body = NULL,
+			created_at = excluded.created_at,
+			expires_at = excluded.expires_at
+		WHERE idempotency_keys.expires_at <= excluded.created_at`,
+		errorset.ErrIdempotencyKeyExists,
+		record.Scope, record.Key, record.RequestHash, now(), formatTime(record.ExpiresAt),
+	)
+}
+
+// GetIdempotencyKey retrieves a live idempotency key from the SQLite database
+func (s *SQLite) GetIdempotencyKey(ctx context.Context, scope, key string) (*idempotency.Record, error) {
+	stmt, err := s.db.PrepareContext(ctx, `
+		SELECT scope, idempotency_key, request_hash, status_code, response_body, created_at, expires_at
+		FROM idempotency_keys WHERE scope = ?1 AND idempotency_key = ?2 AND expires_at > ?3`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var record idempotency.Record
+	var statusCode sql.NullInt64
+	err = stmt.QueryRowContext(ctx, scope, key, now()).Scan(
+		&record.Scope,
+		&record.Key,
+		&record.RequestHash,
+		&statusCode,
+		&record.Body,
+		timeValue(&record.CreatedAt),
+		timeValue(&record.ExpiresAt),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrIdempotencyKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	record.StatusCode = int(statusCode.Int64)
+
+	return &record, nil
+}
+
+// CompleteIdempotencyKey stores the response of the first request of an idempotency key in the SQLite database
+func (s *SQLite) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, body []byte) error {
+	return s.execAffectingOne(ctx,
+		"UPDATE idempotency_keys SET status_code = ?3, response_body = ?4 WHERE scope = ?1 AND idempotency_key = ?2",
+		errorset.ErrIdempotencyKeyNotFound,
+		scope, key, statusCode, body,
+	)
+}
+
+// DeleteIdempotencyKey releases an idempotency key in the SQLite database, so the request can be retried
+func (s *SQLite) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE scope = ?1 AND idempotency_key = ?2", scope, key); err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes the expired idempotency keys from the SQLite database
+func (s *SQLite) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= ?1", now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
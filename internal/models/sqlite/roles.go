@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/audit"
+)
+
+// GetRoles retrieves every role name from the SQLite database
+func (s *SQLite) GetRoles(ctx context.Context) ([]string, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT role_name FROM roles ORDER BY role_name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return roles, nil
+}
+
+// SaveRole inserts a new role into the SQLite database
+func (s *SQLite) SaveRole(ctx context.Context, role string) error {
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO roles (role_name, created_at) VALUES (?1, ?2)")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err := stmt.ExecContext(ctx, role, now()); err != nil {
+		if isUniqueViolation(err) {
+			return errorset.ErrDuplicateRole
+		}
+
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return nil
+}
+
+// SaveAuditLog inserts a new audit log entry into the SQLite database
+func (s *SQLite) SaveAuditLog(ctx context.Context, entry *audit.Entry) (int64, error) {
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO audit_logs (actor_id, action, target_user_id, target_task_id, details, created_at) VALUES (?1, ?2, ?3, ?4, ?5, ?6) RETURNING audit_log_id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var auditLogID int64
+	err = stmt.QueryRowContext(ctx, entry.ActorID, entry.Action, entry.TargetUserID, entry.TargetTaskID, entry.Details, now()).Scan(&auditLogID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return auditLogID, nil
+}
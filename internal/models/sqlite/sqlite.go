@@ -0,0 +1,362 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"restapi/internal/config"
+	"restapi/internal/errorset"
+	"restapi/internal/lib/hashtool"
+	"restapi/internal/models/user"
+	"restapi/internal/storage"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// timeFormat has a fixed width, so timestamps stored as TEXT sort chronologically
+const timeFormat = "2006-01-02T15:04:05.000000000Z"
+
+// SQLite implements the Storage interface for SQLite
+type SQLite struct {
+	db     *sql.DB
+	config *config.Config
+}
+
+// NewSQLite creates a new SQLite on the database file at cfg.Database.Path
+func NewSQLite(cfg *config.Config) storage.Storage {
+	db, err := Open(cfg.Database.Path)
+	if err != nil {
+		log.Fatalf("failed to open database: %v", err)
+	}
+
+	return &SQLite{db: db, config: cfg}
+}
+
+// Open opens the SQLite database file at path with foreign keys enforced
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite has a single writer; one connection serializes transactions instead of failing them with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// SaveUser inserts a new user record into the SQLite database
+func (s *SQLite) SaveUser(ctx context.Context, username, password string) (int64, error) {
+	hashedPassword, err := hashtool.BcryptHashing(password)
+	if err != nil {
+		return 0, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO users (username, password, created_at) VALUES (?1, ?2, ?3) RETURNING user_id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var userID int64
+	err = stmt.QueryRowContext(ctx, username, hashedPassword, now()).Scan(&userID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, errorset.ErrDuplicateUser
+		}
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return userID, nil
+}
+
+// GetUserByID retrieves a record from the SQLite database by key
+func (s *SQLite) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	return s.getUser(ctx, "user_id = ?1", id)
+}
+
+// GetUserByUsername retrieves a record from the SQLite database by username
+func (s *SQLite) GetUserByUsername(ctx context.Context, username string) (*user.User, error) {
+	return s.getUser(ctx, "username = ?1", username)
+}
+
+func (s *SQLite) getUser(ctx context.Context, where string, value any) (*user.User, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT user_id, username, password, role, disabled_at, created_at FROM users WHERE "+where)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var user user.User
+	err = stmt.QueryRowContext(ctx, value).Scan(&user.UserID, &user.UserName, &user.Password, &user.Role, nullTime(&user.DisabledAt), timeValue(&user.CreatedAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return &user, nil
+}
+
+// UsernameExists checks if a record with the given username exists in the SQLite database
+func (s *SQLite) UsernameExists(ctx context.Context, name string) (bool, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT 1 FROM users WHERE username = ?1")
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var exists bool
+	err = stmt.QueryRowContext(ctx, name).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return exists, nil
+}
+
+// UpdateUsername renames a user in the SQLite database
+func (s *SQLite) UpdateUsername(ctx context.Context, id int64, username string) error {
+	err := s.execAffectingOne(ctx, "UPDATE users SET username = ?1 WHERE user_id = ?2", errorset.ErrUserNotFound, username, id)
+	if isUniqueViolation(err) {
+		return errorset.ErrDuplicateUser
+	}
+
+	return err
+}
+
+// UpdateUserPassword updates a record in the SQLite database.
+// Changing the password also invalidates every token issued to the user.
+func (s *SQLite) UpdateUserPassword(ctx context.Context, id int64, password string) error {
+	hashedPassword, err := hashtool.BcryptHashing(password)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return s.updateUserAndRevokeTokens(ctx, "password = ?3", id, hashedPassword)
+}
+
+// DeleteUser deletes a record and, by cascade, its tasks and tokens from the SQLite database
+func (s *SQLite) DeleteUser(ctx context.Context, id int64) error {
+	return s.execAffectingOne(ctx, "DELETE FROM users WHERE user_id = ?1", errorset.ErrUserNotFound, id)
+}
+
+// GetUsers retrieves every user record from the SQLite database
+func (s *SQLite) GetUsers(ctx context.Context) ([]*user.User, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT user_id, username, role, disabled_at, created_at FROM users ORDER BY user_id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	rows, err := stmt.QueryContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	users := []*user.User{}
+	for rows.Next() {
+		var user user.User
+		if err := rows.Scan(&user.UserID, &user.UserName, &user.Role, nullTime(&user.DisabledAt), timeValue(&user.CreatedAt)); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		users = append(users, &user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return users, nil
+}
+
+// SetUserDisabled disables or enables a user in the SQLite database.
+// Disabling also invalidates every token issued to the user.
+func (s *SQLite) SetUserDisabled(ctx context.Context, id int64, disabled bool) error {
+	if !disabled {
+		return s.execAffectingOne(ctx, "UPDATE users SET disabled_at = NULL WHERE user_id = ?1", errorset.ErrUserNotFound, id)
+	}
+
+	return s.updateUserAndRevokeTokens(ctx, "disabled_at = COALESCE(disabled_at, ?2)", id)
+}
+
+// SetUserRole changes the role of a user in the SQLite database.
+// Tokens issued so far carry the old role, so they are invalidated.
+func (s *SQLite) SetUserRole(ctx context.Context, id int64, role string) error {
+	err := s.updateUserAndRevokeTokens(ctx, "role = ?3", id, role)
+	if isForeignKeyViolation(err) {
+		return errorset.ErrRoleNotFound
+	}
+
+	return err
+}
+
+// updateUserAndRevokeTokens applies set, which refers to the current time as ?2 and to values as ?3...,
+// to a user, and moves tokens_valid_after to now and revokes the refresh tokens of the user
+func (s *SQLite) updateUserAndRevokeTokens(ctx context.Context, set string, id int64, values ...any) error {
+	if set != "" {
+		set += ", "
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current := now()
+
+	result, err := tx.ExecContext(ctx, "UPDATE users SET "+set+"tokens_valid_after = ?2 WHERE user_id = ?1", append([]any{id, current}, values...)...)
+	if err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return errorset.ErrUserNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = ?1 WHERE user_id = ?2 AND revoked_at IS NULL", current, id); err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// execAffectingOne runs statement and returns notFound if it affected no row
+func (s *SQLite) execAffectingOne(ctx context.Context, statement string, notFound error, args ...any) error {
+	stmt, err := s.db.PrepareContext(ctx, statement)
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	result, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to retrieve rows affected: %w", err)
+	} else if rowsAffected == 0 {
+		return notFound
+	}
+
+	return nil
+}
+
+// Ping checks the connection to the SQLite database
+func (s *SQLite) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Stats returns the connection pool statistics of the SQLite database
+func (s *SQLite) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// Close closes the connection to the SQLite database
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+// isUniqueViolation is the SQLite counterpart of the PostgreSQL error code 23505
+func isUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) &&
+		(sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE || sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY)
+}
+
+// isForeignKeyViolation is the SQLite counterpart of errorset.ErrForeignKeyConstraintViolation
+func isForeignKeyViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	return errors.As(err, &sqliteErr) && sqliteErr.Code() == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+}
+
+func now() string {
+	return formatTime(time.Now())
+}
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+func formatNullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+
+	return formatTime(*t)
+}
+
+// timeScanner scans a TEXT timestamp into dest
+type timeScanner struct {
+	dest *time.Time
+}
+
+func timeValue(dest *time.Time) sql.Scanner {
+	return timeScanner{dest: dest}
+}
+
+func (s timeScanner) Scan(src any) error {
+	switch value := src.(type) {
+	case string:
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return fmt.Errorf("failed to parse timestamp %q: %w", value, err)
+		}
+		*s.dest = parsed
+	case time.Time:
+		*s.dest = value
+	case nil:
+		*s.dest = time.Time{}
+	default:
+		return fmt.Errorf("unsupported timestamp type %T", src)
+	}
+
+	return nil
+}
+
+// nullTimeScanner scans a nullable TEXT timestamp into dest
+type nullTimeScanner struct {
+	dest **time.Time
+}
+
+func nullTime(dest **time.Time) sql.Scanner {
+	return nullTimeScanner{dest: dest}
+}
+
+func (s nullTimeScanner) Scan(src any) error {
+	if src == nil {
+		*s.dest = nil
+		return nil
+	}
+
+	var t time.Time
+	if err := timeValue(&t).Scan(src); err != nil {
+		return err
+	}
+	*s.dest = &t
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package sqlite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"restapi/internal/storage"
+	"restapi/internal/storage/storagetest"
+)
+
+func TestConformance(t *testing.T) {
+	migrations, err := filepath.Glob("../../../migration/sqlite/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("failed to find migrations: %v", err)
+	}
+
+	storagetest.Run(t, func(t *testing.T) storage.Storage {
+		db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		for _, migration := range migrations {
+			script, err := os.ReadFile(migration)
+			if err != nil {
+				t.Fatalf("failed to read migration: %v", err)
+			}
+
+			if _, err := db.Exec(string(script)); err != nil {
+				t.Fatalf("failed to apply %s: %v", migration, err)
+			}
+		}
+
+		return &SQLite{db: db}
+	})
+}
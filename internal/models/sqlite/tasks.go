@@ -0,0 +1,445 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/task"
+)
+
+// taskColumns is the column list scanTask expects
+const taskColumns = "task_id, user_id, task_content, status, priority, due_at, completed_at, created_at, updated_at, version, deleted_at"
+
+// noDueDate sorts after every stored due date
+const noDueDate = "9999-12-31T23:59:59.999999999Z"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask scans the taskColumns of row
+func scanTask(row rowScanner) (*task.Task, error) {
+	var task task.Task
+	err := row.Scan(
+		&task.TaskID,
+		&task.UserID,
+		&task.TaskContent,
+		&task.Status,
+		&task.Priority,
+		nullTime(&task.DueAt),
+		nullTime(&task.CompletedAt),
+		timeValue(&task.CreatedAt),
+		timeValue(&task.UpdatedAt),
+		&task.Version,
+		nullTime(&task.DeletedAt),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+// SaveTask inserts a new task record into the SQLite database
+func (s *SQLite) SaveTask(ctx context.Context, newTask *task.Task) (int64, error) {
+	stmt, err := s.db.PrepareContext(ctx, `INSERT INTO tasks (user_id, task_content, status, priority, due_at, completed_at, created_at, updated_at)
+		VALUES (?1, ?2, ?3, ?4, ?5, CASE WHEN ?3 = 'done' THEN ?6 END, ?6, ?6) RETURNING task_id`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	status := newTask.Status
+	if status == "" {
+		status = task.StatusTodo
+	}
+
+	var taskID int64
+	err = stmt.QueryRowContext(ctx, newTask.UserID, newTask.TaskContent, status, newTask.Priority, formatNullTime(newTask.DueAt), now()).Scan(&taskID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, errorset.ErrUserNotFound
+		}
+
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return taskID, nil
+}
+
+// sortColumns maps task sort fields to their SQL expression.
+// Tasks without due date sort after every date.
+var sortColumns = map[string]string{
+	task.SortCreatedAt: "created_at",
+	task.SortUpdatedAt: "updated_at",
+	task.SortDueAt:     "COALESCE(due_at, '" + noDueDate + "')",
+	task.SortPriority:  "priority",
+}
+
+// GetTasksByUserID retrieves one page of the tasks of a user matching the query
+func (s *SQLite) GetTasksByUserID(ctx context.Context, userID int64, query task.Query) (*task.Page, error) {
+	if _, err := s.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, errorset.ErrUserNotFound) {
+			return nil, errorset.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	args := []any{userID}
+	arg := func(value any) string {
+		args = append(args, value)
+		return "?" + strconv.Itoa(len(args))
+	}
+	list := func(values []any) string {
+		placeholders := make([]string, 0, len(values))
+		for _, value := range values {
+			placeholders = append(placeholders, arg(value))
+		}
+		return "(" + strings.Join(placeholders, ", ") + ")"
+	}
+
+	where := []string{"user_id = ?1", "deleted_at IS NULL"}
+	if len(query.Statuses) > 0 {
+		statuses := make([]any, 0, len(query.Statuses))
+		for _, status := range query.Statuses {
+			statuses = append(statuses, status)
+		}
+		where = append(where, "status IN "+list(statuses))
+	}
+	if len(query.Priorities) > 0 {
+		priorities := make([]any, 0, len(query.Priorities))
+		for _, priority := range query.Priorities {
+			priorities = append(priorities, priority)
+		}
+		where = append(where, "priority IN "+list(priorities))
+	}
+	if query.DueAfter != nil {
+		where = append(where, "due_at >= "+arg(formatTime(*query.DueAfter)))
+	}
+	if query.DueBefore != nil {
+		where = append(where, "due_at < "+arg(formatTime(*query.DueBefore)))
+	}
+	if query.CreatedSince != nil {
+		where = append(where, "created_at >= "+arg(formatTime(*query.CreatedSince)))
+	}
+	if query.Text != "" {
+		// instr matches the text literally, LIKE would need escaping
+		where = append(where, "instr(lower(task_content), lower("+arg(query.Text)+")) > 0")
+	}
+
+	orderBy := make([]string, 0, len(query.Sort)+1)
+	for _, key := range query.Sort {
+		direction := " ASC"
+		if key.Desc {
+			direction = " DESC"
+		}
+		orderBy = append(orderBy, sortColumns[key.Field]+direction)
+	}
+	orderBy = append(orderBy, "task_id ASC")
+
+	// keyset pagination: (k1 after v1) OR (k1 = v1 AND k2 after v2) OR ... OR (all equal AND task_id > id)
+	if query.Cursor != nil {
+		var keyset []string
+		var equal []string
+
+		for i, key := range query.Sort {
+			column := sortColumns[key.Field]
+			value, err := cursorValue(key.Field, query.Cursor.Values[i])
+			if err != nil {
+				return nil, err
+			}
+			placeholder := arg(value)
+
+			operator := " > "
+			if key.Desc {
+				operator = " < "
+			}
+
+			keyset = append(keyset, "("+strings.Join(append(slices.Clone(equal), column+operator+placeholder), " AND ")+")")
+			equal = append(equal, column+" = "+placeholder)
+		}
+
+		keyset = append(keyset, "("+strings.Join(append(equal, "task_id > "+arg(query.Cursor.TaskID)), " AND ")+")")
+		where = append(where, "("+strings.Join(keyset, " OR ")+")")
+	}
+
+	statement := "SELECT " + taskColumns + " FROM tasks WHERE " + strings.Join(where, " AND ") +
+		" ORDER BY " + strings.Join(orderBy, ", ") +
+		" LIMIT " + arg(query.Limit+1)
+
+	tasks, err := s.queryTasks(ctx, statement, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &task.Page{Tasks: tasks}
+
+	// one row more than the limit was fetched to know whether another page exists
+	if len(page.Tasks) > query.Limit {
+		page.Tasks = page.Tasks[:query.Limit]
+		page.NextCursor = task.EncodeCursor(page.Tasks[query.Limit-1], query.Sort)
+	}
+
+	return page, nil
+}
+
+// cursorValue converts a cursor value to the stored form of field
+func cursorValue(field, value string) (any, error) {
+	switch field {
+	case task.SortPriority:
+		return strconv.Atoi(value)
+	case task.SortDueAt:
+		if value == task.NoDueDate {
+			return noDueDate, nil
+		}
+	}
+
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", task.ErrInvalidQuery)
+	}
+
+	return formatTime(parsed), nil
+}
+
+// SearchTasks ranks the tasks of a user with the portable task.Search, SQLite has no tsvector
+func (s *SQLite) SearchTasks(ctx context.Context, userID int64, query task.SearchQuery) ([]*task.SearchResult, error) {
+	if _, err := s.GetUserByID(ctx, userID); err != nil {
+		if errors.Is(err, errorset.ErrUserNotFound) {
+			return nil, errorset.ErrUserNotFound
+		}
+
+		return nil, err
+	}
+
+	tasks, err := s.queryTasks(ctx, "SELECT "+taskColumns+" FROM tasks WHERE user_id = ?1 AND deleted_at IS NULL", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return task.Search(tasks, query), nil
+}
+
+func (s *SQLite) queryTasks(ctx context.Context, statement string, args ...any) ([]*task.Task, error) {
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []*task.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		tasks = append(tasks, task)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate rows: %w", err)
+	}
+
+	return tasks, nil
+}
+
+// GetTaskByTaskID retrieves a record owned by userID and not in the trash from the SQLite database
+func (s *SQLite) GetTaskByTaskID(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT "+taskColumns+" FROM tasks WHERE task_id = ?1 AND user_id = ?2 AND deleted_at IS NULL")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	task, err := scanTask(stmt.QueryRowContext(ctx, taskID, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return task, nil
+}
+
+// UpdateTaskContent updates a record owned by userID in the SQLite database if it is still at version
+// and returns its new version
+func (s *SQLite) UpdateTaskContent(ctx context.Context, userID, taskID int64, content string, version int64) (int64, error) {
+	updated, err := s.updateTask(ctx, "task_content = ?5", userID, taskID, version, content)
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// UpdateTaskStatus sets the status of a task if it is still at version and returns the task;
+// completed_at follows the done status
+func (s *SQLite) UpdateTaskStatus(ctx context.Context, userID, taskID int64, status string, version int64) (*task.Task, error) {
+	return s.updateTask(ctx,
+		"status = ?5, completed_at = CASE WHEN ?5 = 'done' THEN COALESCE(completed_at, ?3) END",
+		userID, taskID, version, status,
+	)
+}
+
+// ToggleTaskCompletion marks an open task as done and a done task as todo if it is still at version
+func (s *SQLite) ToggleTaskCompletion(ctx context.Context, userID, taskID int64, version int64) (*task.Task, error) {
+	return s.updateTask(ctx, `status = CASE WHEN status = 'done' THEN 'todo' ELSE 'done' END,
+			completed_at = CASE WHEN status = 'done' THEN NULL ELSE ?3 END`,
+		userID, taskID, version,
+	)
+}
+
+// UpdateTaskDueAt sets or, with a nil dueAt, clears the due date of a task if it is still at version
+// and returns its new version
+func (s *SQLite) UpdateTaskDueAt(ctx context.Context, userID, taskID int64, dueAt *time.Time, version int64) (int64, error) {
+	updated, err := s.updateTask(ctx, "due_at = ?5", userID, taskID, version, formatNullTime(dueAt))
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// UpdateTaskPriority sets the priority of a task if it is still at version and returns its new version
+func (s *SQLite) UpdateTaskPriority(ctx context.Context, userID, taskID int64, priority int, version int64) (int64, error) {
+	updated, err := s.updateTask(ctx, "priority = ?5", userID, taskID, version, priority)
+	if err != nil {
+		return 0, err
+	}
+
+	return updated.Version, nil
+}
+
+// PatchTask writes the fields set in patch to a task owned by userID if it is still at version
+// and returns the task. An empty patch writes nothing.
+func (s *SQLite) PatchTask(ctx context.Context, userID, taskID int64, patch task.Patch, version int64) (*task.Task, error) {
+	if patch.IsEmpty() {
+		current, err := s.GetTaskByTaskID(ctx, userID, taskID)
+		if err != nil {
+			return nil, err
+		}
+		if version != task.AnyVersion && current.Version != version {
+			return nil, errorset.ErrVersionMismatch
+		}
+		return current, nil
+	}
+
+	// ?1 to ?4 are taken by updateTask
+	var values []any
+	arg := func(value any) string {
+		values = append(values, value)
+		return "?" + strconv.Itoa(len(values)+4)
+	}
+
+	var set []string
+	if patch.TaskContent != nil {
+		set = append(set, "task_content = "+arg(*patch.TaskContent))
+	}
+	if patch.Status != nil {
+		status := arg(*patch.Status)
+		set = append(set, "status = "+status, "completed_at = CASE WHEN "+status+" = 'done' THEN COALESCE(completed_at, ?3) END")
+	}
+	if patch.Priority != nil {
+		set = append(set, "priority = "+arg(*patch.Priority))
+	}
+	if patch.SetDueAt {
+		set = append(set, "due_at = "+arg(formatNullTime(patch.DueAt)))
+	}
+
+	return s.updateTask(ctx, strings.Join(set, ", "), userID, taskID, version, values...)
+}
+
+// updateTask applies set, which refers to the current time as ?3 and to values as ?5...,
+// to a task owned by userID at version, bumps updated_at and version and returns the updated task
+func (s *SQLite) updateTask(ctx context.Context, set string, userID, taskID, version int64, values ...any) (*task.Task, error) {
+	stmt, err := s.db.PrepareContext(ctx, "UPDATE tasks SET "+set+", updated_at = ?3, version = version + 1"+
+		" WHERE task_id = ?1 AND user_id = ?2 AND deleted_at IS NULL AND (?4 = 0 OR version = ?4) RETURNING "+taskColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	updated, err := scanTask(stmt.QueryRowContext(ctx, append([]any{taskID, userID, now(), version}, values...)...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, s.missedTask(ctx, userID, taskID)
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return updated, nil
+}
+
+// DeleteTask moves a record owned by userID in the SQLite database to the trash if it is still at version
+func (s *SQLite) DeleteTask(ctx context.Context, userID, taskID int64, version int64) error {
+	_, err := s.updateTask(ctx, "deleted_at = ?3", userID, taskID, version)
+	return err
+}
+
+// GetTrashedTasks retrieves the tasks of a user in the trash, most recently deleted first
+func (s *SQLite) GetTrashedTasks(ctx context.Context, userID int64) ([]*task.Task, error) {
+	return s.queryTasks(ctx, "SELECT "+taskColumns+" FROM tasks WHERE user_id = ?1 AND deleted_at IS NOT NULL"+
+		" ORDER BY deleted_at DESC, task_id ASC", userID)
+}
+
+// RestoreTask takes a task owned by userID out of the trash
+func (s *SQLite) RestoreTask(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	stmt, err := s.db.PrepareContext(ctx, `UPDATE tasks SET deleted_at = NULL, updated_at = ?3, version = version + 1
+		WHERE task_id = ?1 AND user_id = ?2 AND deleted_at IS NOT NULL
+		RETURNING `+taskColumns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	task, err := scanTask(stmt.QueryRowContext(ctx, taskID, userID, now()))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrTaskNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return task, nil
+}
+
+// PurgeTask deletes a task owned by userID in the trash from the SQLite database for good
+func (s *SQLite) PurgeTask(ctx context.Context, userID, taskID int64) error {
+	return s.execAffectingOne(ctx, "DELETE FROM tasks WHERE task_id = ?1 AND user_id = ?2 AND deleted_at IS NOT NULL",
+		errorset.ErrTaskNotFound, taskID, userID)
+}
+
+// PurgeTrashedTasks deletes the tasks moved to the trash before the given time for good
+func (s *SQLite) PurgeTrashedTasks(ctx context.Context, before time.Time) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM tasks WHERE deleted_at < ?1", formatTime(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// missedTask tells why a write to a task owned by userID at a version affected no row:
+// the task is gone or it has another version
+func (s *SQLite) missedTask(ctx context.Context, userID, taskID int64) error {
+	if _, err := s.GetTaskByTaskID(ctx, userID, taskID); err != nil {
+		return err
+	}
+
+	return errorset.ErrVersionMismatch
+}
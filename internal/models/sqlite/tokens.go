@@ -0,0 +1,219 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/models/token"
+)
+
+// SaveRefreshToken inserts a new refresh token record into the SQLite database
+func (s *SQLite) SaveRefreshToken(ctx context.Context, refreshToken *token.RefreshToken) (int64, error) {
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO refresh_tokens (token_hash, user_id, family_id, expires_at, created_at) VALUES (?1, ?2, ?3, ?4, ?5) RETURNING token_id")
+	if err != nil {
+		return 0, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var tokenID int64
+	err = stmt.QueryRowContext(ctx, refreshToken.TokenHash, refreshToken.UserID, refreshToken.FamilyID, formatTime(refreshToken.ExpiresAt), now()).Scan(&tokenID)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return 0, errorset.ErrUserNotFound
+		}
+
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return tokenID, nil
+}
+
+// GetRefreshTokenByHash retrieves a refresh token record from the SQLite database by its hash
+func (s *SQLite) GetRefreshTokenByHash(ctx context.Context, hash string) (*token.RefreshToken, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT token_id, token_hash, user_id, family_id, expires_at, created_at, revoked_at FROM refresh_tokens WHERE token_hash = ?1")
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var refreshToken token.RefreshToken
+	err = stmt.QueryRowContext(ctx, hash).Scan(
+		&refreshToken.TokenID,
+		&refreshToken.TokenHash,
+		&refreshToken.UserID,
+		&refreshToken.FamilyID,
+		timeValue(&refreshToken.ExpiresAt),
+		timeValue(&refreshToken.CreatedAt),
+		nullTime(&refreshToken.RevokedAt),
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return &refreshToken, nil
+}
+
+// RotateRefreshToken revokes the token with oldHash and stores newHash in the same family.
+// Presenting a token that was already rotated or revoked revokes the whole family.
+func (s *SQLite) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*token.RefreshToken, error) {
+	// the single connection serializes transactions, so no row lock is needed
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var old token.RefreshToken
+	err = tx.QueryRowContext(ctx,
+		"SELECT token_id, user_id, family_id, expires_at, revoked_at FROM refresh_tokens WHERE token_hash = ?1",
+		oldHash,
+	).Scan(&old.TokenID, &old.UserID, &old.FamilyID, timeValue(&old.ExpiresAt), nullTime(&old.RevokedAt))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errorset.ErrRefreshTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	current := now()
+
+	if old.RevokedAt != nil {
+		if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = ?1 WHERE family_id = ?2 AND revoked_at IS NULL", current, old.FamilyID); err != nil {
+			return nil, fmt.Errorf("failed to execute statement: %w", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return nil, fmt.Errorf("failed to commit transaction: %w", err)
+		}
+
+		return nil, errorset.ErrRefreshTokenReused
+	}
+
+	if time.Now().After(old.ExpiresAt) {
+		return nil, errorset.ErrRefreshTokenExpired
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE refresh_tokens SET revoked_at = ?1 WHERE token_id = ?2", current, old.TokenID); err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	next := token.RefreshToken{
+		TokenHash: newHash,
+		UserID:    old.UserID,
+		FamilyID:  old.FamilyID,
+		ExpiresAt: expiresAt,
+	}
+	err = tx.QueryRowContext(ctx,
+		"INSERT INTO refresh_tokens (token_hash, user_id, family_id, expires_at, created_at) VALUES (?1, ?2, ?3, ?4, ?5) RETURNING token_id, created_at",
+		next.TokenHash, next.UserID, next.FamilyID, formatTime(next.ExpiresAt), current,
+	).Scan(&next.TokenID, timeValue(&next.CreatedAt))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &next, nil
+}
+
+// RevokeRefreshTokenFamily revokes every active refresh token of a family
+func (s *SQLite) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	stmt, err := s.db.PrepareContext(ctx, "UPDATE refresh_tokens SET revoked_at = ?1 WHERE family_id = ?2 AND revoked_at IS NULL")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, now(), familyID); err != nil {
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return nil
+}
+
+// RevokeAccessToken adds an access token to the denylist until it expires
+func (s *SQLite) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	stmt, err := s.db.PrepareContext(ctx, "INSERT INTO revoked_tokens (jti, user_id, expires_at, revoked_at) VALUES (?1, ?2, ?3, ?4) ON CONFLICT (jti) DO NOTHING")
+	if err != nil {
+		return fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	if _, err = stmt.ExecContext(ctx, jti, userID, formatTime(expiresAt), now()); err != nil {
+		if isForeignKeyViolation(err) {
+			return errorset.ErrUserNotFound
+		}
+
+		return fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return nil
+}
+
+// IsAccessTokenRevoked checks if an access token is on the denylist
+func (s *SQLite) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT 1 FROM revoked_tokens WHERE jti = ?1")
+	if err != nil {
+		return false, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var revoked bool
+	err = stmt.QueryRowContext(ctx, jti).Scan(&revoked)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpiredRevokedTokens removes the revoked access tokens that have expired anyway from the SQLite database
+func (s *SQLite) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at <= ?1", now())
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to retrieve rows affected: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// GetTokensValidAfter retrieves the time before which the user's access tokens are rejected.
+// The zero time is returned if the user never revoked their tokens.
+func (s *SQLite) GetTokensValidAfter(ctx context.Context, userID int64) (time.Time, error) {
+	stmt, err := s.db.PrepareContext(ctx, "SELECT tokens_valid_after FROM users WHERE user_id = ?1")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to prepare statement: %w", err)
+	}
+	defer stmt.Close()
+
+	var validAfter time.Time
+	err = stmt.QueryRowContext(ctx, userID).Scan(timeValue(&validAfter))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return time.Time{}, errorset.ErrUserNotFound
+		}
+		return time.Time{}, fmt.Errorf("failed to execute statement: %w", err)
+	}
+
+	return validAfter, nil
+}
+
+// RevokeUserTokens invalidates every access and refresh token issued to the user so far
+func (s *SQLite) RevokeUserTokens(ctx context.Context, userID int64) error {
+	return s.updateUserAndRevokeTokens(ctx, "", userID)
+}
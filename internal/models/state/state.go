@@ -1,14 +1,12 @@
 package state
 
 const (
-	Err     = "Error"
 	Success = "Success"
 )
 
 // State of response
 type State struct {
-	Status string `json:"status"` // Error or Success
-	Error  string `json:"error,omitempty"`
+	Status string `json:"status"` // Success, errors are answered with a problem instead
 }
 
 // OK returns a success state
@@ -17,11 +15,3 @@ func OK() State {
 		Status: Success,
 	}
 }
-
-// Error returns an error state
-func Error(err string) State {
-	return State{
-		Status: Err,
-		Error:  err,
-	}
-}
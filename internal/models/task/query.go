@@ -0,0 +1,318 @@
+package task
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SortCreatedAt = "createdAt"
+	SortUpdatedAt = "updatedAt"
+	SortDueAt     = "dueAt"
+	SortPriority  = "priority"
+
+	DefaultLimit = 50
+	MaxLimit     = 200
+
+	// NoDueDate is the cursor value of a task without due date, which sorts after every date
+	NoDueDate = "infinity"
+)
+
+var ErrInvalidQuery = errors.New("invalid task query")
+
+// SortKey orders tasks by one field
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+// Query selects, orders and pages the tasks of a user.
+// Zero values mean no filter.
+type Query struct {
+	Statuses     []string
+	Priorities   []int
+	DueAfter     *time.Time
+	DueBefore    *time.Time
+	CreatedSince *time.Time
+	Text         string
+	// Sort always ends with the task ID, so the order is total
+	Sort   []SortKey
+	Limit  int
+	Cursor *Cursor
+}
+
+// Cursor points right after the last task of a page
+type Cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+	TaskID int64    `json:"id"`
+}
+
+// Page is one page of tasks; NextCursor is empty on the last page
+type Page struct {
+	Tasks      []*Task `json:"tasks"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// DefaultQuery returns all tasks oldest first
+func DefaultQuery() Query {
+	return Query{
+		Sort:  []SortKey{{Field: SortCreatedAt}},
+		Limit: DefaultLimit,
+	}
+}
+
+// ParseQuery reads a Query from URL query parameters:
+//
+//	status=todo,in_progress  priority=2,3  due_after=RFC3339  due_before=RFC3339
+//	created_since=RFC3339  q=text  sort=-priority,dueAt  limit=50  cursor=...
+func ParseQuery(values url.Values) (Query, error) {
+	query := DefaultQuery()
+	var err error
+
+	if raw := values.Get("status"); raw != "" {
+		for _, status := range strings.Split(raw, ",") {
+			if !slices.Contains([]string{StatusTodo, StatusInProgress, StatusDone}, status) {
+				return Query{}, fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, status)
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+
+	if raw := values.Get("priority"); raw != "" {
+		for _, value := range strings.Split(raw, ",") {
+			priority, err := strconv.Atoi(value)
+			if err != nil || priority < PriorityNone || priority > PriorityHigh {
+				return Query{}, fmt.Errorf("%w: invalid priority %q", ErrInvalidQuery, value)
+			}
+			query.Priorities = append(query.Priorities, priority)
+		}
+	}
+
+	if query.DueAfter, err = parseTime(values, "due_after"); err != nil {
+		return Query{}, err
+	}
+	if query.DueBefore, err = parseTime(values, "due_before"); err != nil {
+		return Query{}, err
+	}
+	if query.CreatedSince, err = parseTime(values, "created_since"); err != nil {
+		return Query{}, err
+	}
+
+	query.Text = strings.TrimSpace(values.Get("q"))
+
+	if raw := values.Get("sort"); raw != "" {
+		query.Sort = nil
+		for _, field := range strings.Split(raw, ",") {
+			key := SortKey{Field: strings.TrimPrefix(field, "-"), Desc: strings.HasPrefix(field, "-")}
+			if !slices.Contains([]string{SortCreatedAt, SortUpdatedAt, SortDueAt, SortPriority}, key.Field) {
+				return Query{}, fmt.Errorf("%w: unknown sort field %q", ErrInvalidQuery, key.Field)
+			}
+			query.Sort = append(query.Sort, key)
+		}
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		query.Limit, err = strconv.Atoi(raw)
+		if err != nil || query.Limit < 1 || query.Limit > MaxLimit {
+			return Query{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxLimit)
+		}
+	}
+
+	if raw := values.Get("cursor"); raw != "" {
+		if query.Cursor, err = DecodeCursor(raw, query.Sort); err != nil {
+			return Query{}, err
+		}
+	}
+
+	return query, nil
+}
+
+func parseTime(values url.Values, key string) (*time.Time, error) {
+	raw := values.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be an RFC 3339 timestamp", ErrInvalidQuery, key)
+	}
+
+	return &parsed, nil
+}
+
+// SortString returns the sort keys in the sort parameter format
+func SortString(sort []SortKey) string {
+	fields := make([]string, 0, len(sort))
+	for _, key := range sort {
+		if key.Desc {
+			fields = append(fields, "-"+key.Field)
+		} else {
+			fields = append(fields, key.Field)
+		}
+	}
+
+	return strings.Join(fields, ",")
+}
+
+// SortValue returns the value of a sort field in its cursor form
+func (t *Task) SortValue(field string) string {
+	switch field {
+	case SortCreatedAt:
+		return t.CreatedAt.Format(time.RFC3339Nano)
+	case SortUpdatedAt:
+		return t.UpdatedAt.Format(time.RFC3339Nano)
+	case SortDueAt:
+		if t.DueAt == nil {
+			return NoDueDate
+		}
+		return t.DueAt.Format(time.RFC3339Nano)
+	case SortPriority:
+		return strconv.Itoa(t.Priority)
+	}
+
+	return ""
+}
+
+// EncodeCursor returns the cursor of the page that starts after last
+func EncodeCursor(last *Task, sort []SortKey) string {
+	cursor := Cursor{Sort: SortString(sort), TaskID: last.TaskID}
+	for _, key := range sort {
+		cursor.Values = append(cursor.Values, last.SortValue(key.Field))
+	}
+
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor; it must have been issued for the same sort
+func DecodeCursor(raw string, sort []SortKey) (*Cursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(decoded, &cursor); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	if cursor.Sort != SortString(sort) || len(cursor.Values) != len(sort) {
+		return nil, fmt.Errorf("%w: cursor was issued for another sort", ErrInvalidQuery)
+	}
+
+	for i, key := range sort {
+		if err := validateSortValue(key.Field, cursor.Values[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &cursor, nil
+}
+
+func validateSortValue(field, value string) error {
+	var err error
+	switch field {
+	case SortPriority:
+		_, err = strconv.Atoi(value)
+	case SortDueAt:
+		if value != NoDueDate {
+			_, err = time.Parse(time.RFC3339Nano, value)
+		}
+	default:
+		_, err = time.Parse(time.RFC3339Nano, value)
+	}
+
+	if err != nil {
+		return fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	return nil
+}
+
+// Matches reports whether t passes the filters of the query.
+// It is the portable form of the filters for storages without SQL.
+func (q Query) Matches(t *Task) bool {
+	if len(q.Statuses) > 0 && !slices.Contains(q.Statuses, t.Status) {
+		return false
+	}
+	if len(q.Priorities) > 0 && !slices.Contains(q.Priorities, t.Priority) {
+		return false
+	}
+	if q.DueAfter != nil && (t.DueAt == nil || t.DueAt.Before(*q.DueAfter)) {
+		return false
+	}
+	if q.DueBefore != nil && (t.DueAt == nil || !t.DueAt.Before(*q.DueBefore)) {
+		return false
+	}
+	if q.CreatedSince != nil && t.CreatedAt.Before(*q.CreatedSince) {
+		return false
+	}
+	if q.Text != "" && !strings.Contains(strings.ToLower(t.TaskContent), strings.ToLower(q.Text)) {
+		return false
+	}
+
+	return true
+}
+
+// Compare orders a and b by the sort keys of the query, then by task ID
+func (q Query) Compare(a, b *Task) int {
+	for _, key := range q.Sort {
+		if c := compareSortValues(key.Field, a.SortValue(key.Field), b.SortValue(key.Field)); c != 0 {
+			if key.Desc {
+				return -c
+			}
+			return c
+		}
+	}
+
+	return cmp.Compare(a.TaskID, b.TaskID)
+}
+
+// After reports whether t comes after the cursor of the query
+func (q Query) After(t *Task) bool {
+	if q.Cursor == nil {
+		return true
+	}
+
+	for i, key := range q.Sort {
+		if c := compareSortValues(key.Field, t.SortValue(key.Field), q.Cursor.Values[i]); c != 0 {
+			return (c > 0) != key.Desc
+		}
+	}
+
+	return t.TaskID > q.Cursor.TaskID
+}
+
+// compareSortValues compares two cursor form values of field
+func compareSortValues(field, a, b string) int {
+	if field == SortPriority {
+		x, _ := strconv.Atoi(a)
+		y, _ := strconv.Atoi(b)
+		return cmp.Compare(x, y)
+	}
+
+	if a == NoDueDate || b == NoDueDate {
+		return cmp.Compare(boolToInt(a == NoDueDate), boolToInt(b == NoDueDate))
+	}
+
+	x, _ := time.Parse(time.RFC3339Nano, a)
+	y, _ := time.Parse(time.RFC3339Nano, b)
+	return x.Compare(y)
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
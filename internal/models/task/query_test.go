@@ -0,0 +1,56 @@
+package task
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		params  string
+		wantErr bool
+	}{
+		{name: "defaults", params: ""},
+		{name: "all filters", params: "status=todo,done&priority=2,3&due_after=2025-03-01T00:00:00Z&q=milk&sort=-priority,dueAt&limit=10"},
+		{name: "unknown status", params: "status=later", wantErr: true},
+		{name: "priority out of range", params: "priority=9", wantErr: true},
+		{name: "malformed time", params: "due_before=tomorrow", wantErr: true},
+		{name: "unknown sort field", params: "sort=-title", wantErr: true},
+		{name: "limit too large", params: "limit=1000", wantErr: true},
+		{name: "malformed cursor", params: "cursor=bm90LWpzb24", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.params)
+
+			_, err := ParseQuery(values)
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("ParseQuery(%q) error = %v, wantErr %v", tt.params, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidQuery) {
+				t.Fatalf("error %v is not ErrInvalidQuery", err)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	sort := []SortKey{{Field: SortPriority, Desc: true}, {Field: SortDueAt}}
+	last := &Task{TaskID: 42, Priority: PriorityHigh, CreatedAt: time.Now()}
+
+	cursor, err := DecodeCursor(EncodeCursor(last, sort), sort)
+	if err != nil {
+		t.Fatalf("DecodeCursor: %v", err)
+	}
+	if cursor.TaskID != 42 || cursor.Values[0] != "3" || cursor.Values[1] != NoDueDate {
+		t.Fatalf("unexpected cursor %+v", cursor)
+	}
+
+	if _, err := DecodeCursor(EncodeCursor(last, sort), []SortKey{{Field: SortCreatedAt}}); !errors.Is(err, ErrInvalidQuery) {
+		t.Fatalf("cursor for another sort accepted: %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+package task
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+
+	// HighlightStart and HighlightStop enclose the matched words of a snippet
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+
+	// snippetWords is the maximum number of words of a snippet
+	snippetWords = 35
+)
+
+// SearchQuery is a full-text search over the tasks of a user.
+// Every term must match the beginning of a word of the task content.
+type SearchQuery struct {
+	Terms []string
+	Limit int
+}
+
+// SearchResult is a matched task with its rank and highlighted snippet
+type SearchResult struct {
+	Task    *Task   `json:"task"`
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// ParseSearchQuery reads a SearchQuery from URL query parameters:
+//
+//	q=text  limit=20
+func ParseSearchQuery(values url.Values) (SearchQuery, error) {
+	query := SearchQuery{
+		Terms: SearchTerms(values.Get("q")),
+		Limit: DefaultSearchLimit,
+	}
+
+	if len(query.Terms) == 0 {
+		return SearchQuery{}, fmt.Errorf("%w: q must contain at least one word", ErrInvalidQuery)
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > MaxSearchLimit {
+			return SearchQuery{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidQuery, MaxSearchLimit)
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// SearchTerms splits text into lower case words of letters and digits.
+// Everything else is a separator, so terms are safe to embed in a tsquery.
+func SearchTerms(text string) []string {
+	terms := strings.FieldsFunc(strings.ToLower(text), isSeparator)
+	slices.Sort(terms)
+	return slices.Compact(terms)
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// Search is the portable search for storages without a full-text index.
+// It ranks tasks by the share of their words that match a term.
+func Search(tasks []*Task, query SearchQuery) []*SearchResult {
+	results := []*SearchResult{}
+
+	for _, task := range tasks {
+		words := strings.FieldsFunc(task.TaskContent, isSeparator)
+
+		matched := 0
+		found := make(map[string]bool, len(query.Terms))
+		for _, word := range words {
+			lower := strings.ToLower(word)
+			hit := false
+			for _, term := range query.Terms {
+				if strings.HasPrefix(lower, term) {
+					found[term] = true
+					hit = true
+				}
+			}
+			if hit {
+				matched++
+			}
+		}
+
+		if len(found) != len(query.Terms) {
+			continue
+		}
+
+		results = append(results, &SearchResult{
+			Task:    task,
+			Rank:    float64(matched) / float64(len(words)),
+			Snippet: Highlight(task.TaskContent, query.Terms),
+		})
+	}
+
+	slices.SortFunc(results, func(a, b *SearchResult) int {
+		if c := cmp.Compare(b.Rank, a.Rank); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Task.TaskID, b.Task.TaskID)
+	})
+
+	if len(results) > query.Limit {
+		results = results[:query.Limit]
+	}
+
+	return results
+}
+
+// Highlight returns up to snippetWords words of content starting shortly
+// before the first match, with matched words enclosed in HighlightStart and HighlightStop.
+// The content is HTML escaped, so the snippet can be shown as HTML.
+func Highlight(content string, terms []string) string {
+	words := strings.Fields(content)
+
+	start := 0
+	for i, word := range words {
+		if matchTerm(word, terms) {
+			start = max(0, i-snippetWords/4)
+			break
+		}
+	}
+
+	end := min(len(words), start+snippetWords)
+
+	snippet := make([]string, 0, end-start)
+	for _, word := range words[start:end] {
+		snippet = append(snippet, highlightWord(word, terms))
+	}
+
+	return strings.Join(snippet, " ")
+}
+
+// highlightWord marks the parts of a whitespace separated word that match a term
+func highlightWord(word string, terms []string) string {
+	var b strings.Builder
+
+	for word != "" {
+		i := strings.IndexFunc(word, func(r rune) bool { return !isSeparator(r) })
+		if i < 0 {
+			b.WriteString(html.EscapeString(word))
+			break
+		}
+		b.WriteString(html.EscapeString(word[:i]))
+		word = word[i:]
+
+		j := strings.IndexFunc(word, isSeparator)
+		if j < 0 {
+			j = len(word)
+		}
+
+		if matchTerm(word[:j], terms) {
+			b.WriteString(HighlightStart + html.EscapeString(word[:j]) + HighlightStop)
+		} else {
+			b.WriteString(html.EscapeString(word[:j]))
+		}
+		word = word[j:]
+	}
+
+	return b.String()
+}
+
+func matchTerm(word string, terms []string) bool {
+	lower := strings.ToLower(strings.TrimFunc(word, isSeparator))
+	return slices.ContainsFunc(terms, func(term string) bool {
+		return strings.HasPrefix(lower, term)
+	})
+}
@@ -0,0 +1,32 @@
+package task
+
+import "testing"
+
+func TestSearch(t *testing.T) {
+	tasks := []*Task{
+		{TaskID: 1, TaskContent: "Buy groceries and milk for the week"},
+		{TaskID: 2, TaskContent: "Groceries: milk"},
+		{TaskID: 3, TaskContent: "Call the grocer"},
+	}
+
+	results := Search(tasks, SearchQuery{Terms: SearchTerms("MIL groc"), Limit: DefaultSearchLimit})
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+
+	if results[0].Task.TaskID != 2 || results[1].Task.TaskID != 1 {
+		t.Fatalf("unexpected ranking: %d, %d", results[0].Task.TaskID, results[1].Task.TaskID)
+	}
+
+	if want := "<mark>Groceries</mark>: <mark>milk</mark>"; results[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", results[0].Snippet, want)
+	}
+}
+
+func TestHighlightEscapesContent(t *testing.T) {
+	got := Highlight(`<script>alert("milk")</script> & milk`, SearchTerms("milk"))
+	want := `&lt;script&gt;alert(&#34;<mark>milk</mark>&#34;)&lt;/script&gt; &amp; <mark>milk</mark>`
+	if got != want {
+		t.Fatalf("snippet = %q, want %q", got, want)
+	}
+}
@@ -2,9 +2,53 @@ package task
 
 import "time"
 
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusDone       = "done"
+)
+
+const (
+	PriorityNone = iota
+	PriorityLow
+	PriorityMedium
+	PriorityHigh
+)
+
+// AnyVersion matches every version of a task, for writes that don't check it
+const AnyVersion int64 = 0
+
 type Task struct {
-	TaskID      int64     `json:"taskId"`
-	UserID      int64     `json:"userId"`
-	TaskContent string    `json:"taskContent"`
-	CreatedAt   time.Time `json:"createdAt"`
+	TaskID      int64      `json:"taskId"`
+	UserID      int64      `json:"userId"`
+	TaskContent string     `json:"taskContent"`
+	Status      string     `json:"status"`
+	Priority    int        `json:"priority"`
+	DueAt       *time.Time `json:"dueAt"`
+	CompletedAt *time.Time `json:"completedAt"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	UpdatedAt   time.Time  `json:"updatedAt"`
+	Version     int64      `json:"version"`             // bumped by every write
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"` // set while the task is in the trash
+}
+
+// Completed reports whether the write that returned t marked it done. A write that
+// marks a task done sets completedAt to its updatedAt, later writes keep it.
+func (t *Task) Completed() bool {
+	return t.CompletedAt != nil && t.CompletedAt.Equal(t.UpdatedAt)
+}
+
+// Patch lists the fields of a task to change, nil fields keep their value
+type Patch struct {
+	TaskContent *string
+	Status      *string
+	Priority    *int
+	// DueAt is written only if SetDueAt, so nil can clear the due date
+	SetDueAt bool
+	DueAt    *time.Time
+}
+
+// IsEmpty reports whether the patch changes no field
+func (p Patch) IsEmpty() bool {
+	return p.TaskContent == nil && p.Status == nil && p.Priority == nil && !p.SetDueAt
 }
@@ -0,0 +1,14 @@
+package token
+
+import "time"
+
+// RefreshToken is a stored refresh token; only the hash of the raw token is persisted
+type RefreshToken struct {
+	TokenID   int64      `json:"tokenId"`
+	TokenHash string     `json:"-"`
+	UserID    int64      `json:"userId"`
+	FamilyID  string     `json:"familyId"`
+	ExpiresAt time.Time  `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
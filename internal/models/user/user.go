@@ -2,9 +2,21 @@ package user
 
 import "time"
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
-	UserID    int64     `json:"userId"`
-	UserName  string    `json:"username"`
-	Password  string    `json:"-"`
-	CreatedAt time.Time `json:"createdAt"`
+	UserID     int64      `json:"userId"`
+	UserName   string     `json:"username"`
+	Password   string     `json:"-"`
+	Role       string     `json:"role"`
+	DisabledAt *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Disabled reports whether an admin disabled the account
+func (u *User) Disabled() bool {
+	return u.DisabledAt != nil
 }
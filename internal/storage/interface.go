@@ -1,23 +1,67 @@
 package storage
 
 import (
+	"context"
+	"database/sql"
+	"time"
+
+	"restapi/internal/models/audit"
+	"restapi/internal/models/idempotency"
 	"restapi/internal/models/user"
 	"restapi/internal/models/task"
+	"restapi/internal/models/token"
 )
 
 type Storage interface {
-	SaveUser(username, password string) (int64, error)
-	GetUserByID(id int64) (*user.User, error)
-	UsernameExists(name string) (bool, error)
-	UpdateUserPassword(id int64, password string) error
-	DeleteUser(id int64) error
-
-	SaveTask(userId int64, content string) (int64, error)
-	GetTasksByUserID(userID int64) ([]*task.Task, error)
-	GetTaskByTaskID(taskID int64) (*task.Task, error)
-	UpdateTaskContent(task_id int64, content string) error
-	DeleteTask(task_id int64) error
-
-	Ping() error
+	SaveUser(ctx context.Context, username, password string) (int64, error)
+	GetUserByID(ctx context.Context, id int64) (*user.User, error)
+	GetUserByUsername(ctx context.Context, username string) (*user.User, error)
+	UsernameExists(ctx context.Context, name string) (bool, error)
+	UpdateUsername(ctx context.Context, id int64, username string) error
+	UpdateUserPassword(ctx context.Context, id int64, password string) error
+	DeleteUser(ctx context.Context, id int64) error
+	GetUsers(ctx context.Context) ([]*user.User, error)
+	SetUserDisabled(ctx context.Context, id int64, disabled bool) error
+	SetUserRole(ctx context.Context, id int64, role string) error
+
+	GetRoles(ctx context.Context) ([]string, error)
+	SaveRole(ctx context.Context, role string) error
+	SaveAuditLog(ctx context.Context, entry *audit.Entry) (int64, error)
+
+	SaveTask(ctx context.Context, newTask *task.Task) (int64, error)
+	GetTasksByUserID(ctx context.Context, userID int64, query task.Query) (*task.Page, error)
+	SearchTasks(ctx context.Context, userID int64, query task.SearchQuery) ([]*task.SearchResult, error)
+	GetTaskByTaskID(ctx context.Context, userID, taskID int64) (*task.Task, error)
+	UpdateTaskContent(ctx context.Context, userID, task_id int64, content string, version int64) (int64, error)
+	UpdateTaskStatus(ctx context.Context, userID, taskID int64, status string, version int64) (*task.Task, error)
+	ToggleTaskCompletion(ctx context.Context, userID, taskID int64, version int64) (*task.Task, error)
+	UpdateTaskDueAt(ctx context.Context, userID, taskID int64, dueAt *time.Time, version int64) (int64, error)
+	UpdateTaskPriority(ctx context.Context, userID, taskID int64, priority int, version int64) (int64, error)
+	PatchTask(ctx context.Context, userID, taskID int64, patch task.Patch, version int64) (*task.Task, error)
+	DeleteTask(ctx context.Context, userID, task_id int64, version int64) error
+	GetTrashedTasks(ctx context.Context, userID int64) ([]*task.Task, error)
+	RestoreTask(ctx context.Context, userID, taskID int64) (*task.Task, error)
+	PurgeTask(ctx context.Context, userID, taskID int64) error
+	PurgeTrashedTasks(ctx context.Context, before time.Time) (int64, error)
+
+	SaveRefreshToken(ctx context.Context, refreshToken *token.RefreshToken) (int64, error)
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*token.RefreshToken, error)
+	RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*token.RefreshToken, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+
+	RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpiredRevokedTokens(ctx context.Context) (int64, error)
+	GetTokensValidAfter(ctx context.Context, userID int64) (time.Time, error)
+	RevokeUserTokens(ctx context.Context, userID int64) error
+
+	SaveIdempotencyKey(ctx context.Context, record *idempotency.Record) error
+	GetIdempotencyKey(ctx context.Context, scope, key string) (*idempotency.Record, error)
+	CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, body []byte) error
+	DeleteIdempotencyKey(ctx context.Context, scope, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error)
+
+	Ping(ctx context.Context) error
+	Stats() sql.DBStats
 	Close() error
 }
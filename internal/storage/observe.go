@@ -0,0 +1,398 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"restapi/internal/models/audit"
+	"restapi/internal/models/idempotency"
+	"restapi/internal/models/task"
+	"restapi/internal/models/token"
+	"restapi/internal/models/user"
+)
+
+// Observer is told about every storage call, to record metrics or traces
+type Observer interface {
+	// StartCall is called before the call to method. The returned context is passed on to
+	// the storage and done is called with the error the call returned.
+	StartCall(ctx context.Context, method string) (_ context.Context, done func(err error))
+}
+
+// observedStorage reports every call to the wrapped storage to its observers
+type observedStorage struct {
+	next      Storage
+	observers []Observer
+}
+
+// WithObservers wraps s so that observers are told about every call but Stats and Close
+func WithObservers(s Storage, observers ...Observer) Storage {
+	return &observedStorage{next: s, observers: observers}
+}
+
+func (s *observedStorage) start(ctx context.Context, method string) (context.Context, func(err error)) {
+	dones := make([]func(err error), 0, len(s.observers))
+	for _, observer := range s.observers {
+		var done func(err error)
+		ctx, done = observer.StartCall(ctx, method)
+		dones = append(dones, done)
+	}
+
+	return ctx, func(err error) {
+		for i := len(dones) - 1; i >= 0; i-- {
+			dones[i](err)
+		}
+	}
+}
+
+func (s *observedStorage) SaveUser(ctx context.Context, username, password string) (int64, error) {
+	ctx, done := s.start(ctx, "SaveUser")
+
+	result, err := s.next.SaveUser(ctx, username, password)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	ctx, done := s.start(ctx, "GetUserByID")
+
+	result, err := s.next.GetUserByID(ctx, id)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) GetUserByUsername(ctx context.Context, username string) (*user.User, error) {
+	ctx, done := s.start(ctx, "GetUserByUsername")
+
+	result, err := s.next.GetUserByUsername(ctx, username)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) UsernameExists(ctx context.Context, name string) (bool, error) {
+	ctx, done := s.start(ctx, "UsernameExists")
+
+	result, err := s.next.UsernameExists(ctx, name)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) UpdateUsername(ctx context.Context, id int64, username string) error {
+	ctx, done := s.start(ctx, "UpdateUsername")
+
+	err := s.next.UpdateUsername(ctx, id, username)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) UpdateUserPassword(ctx context.Context, id int64, password string) error {
+	ctx, done := s.start(ctx, "UpdateUserPassword")
+
+	err := s.next.UpdateUserPassword(ctx, id, password)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) DeleteUser(ctx context.Context, id int64) error {
+	ctx, done := s.start(ctx, "DeleteUser")
+
+	err := s.next.DeleteUser(ctx, id)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) GetUsers(ctx context.Context) ([]*user.User, error) {
+	ctx, done := s.start(ctx, "GetUsers")
+
+	result, err := s.next.GetUsers(ctx)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) SetUserDisabled(ctx context.Context, id int64, disabled bool) error {
+	ctx, done := s.start(ctx, "SetUserDisabled")
+
+	err := s.next.SetUserDisabled(ctx, id, disabled)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) SetUserRole(ctx context.Context, id int64, role string) error {
+	ctx, done := s.start(ctx, "SetUserRole")
+
+	err := s.next.SetUserRole(ctx, id, role)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) GetRoles(ctx context.Context) ([]string, error) {
+	ctx, done := s.start(ctx, "GetRoles")
+
+	result, err := s.next.GetRoles(ctx)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) SaveRole(ctx context.Context, role string) error {
+	ctx, done := s.start(ctx, "SaveRole")
+
+	err := s.next.SaveRole(ctx, role)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) SaveAuditLog(ctx context.Context, entry *audit.Entry) (int64, error) {
+	ctx, done := s.start(ctx, "SaveAuditLog")
+
+	result, err := s.next.SaveAuditLog(ctx, entry)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) SaveTask(ctx context.Context, newTask *task.Task) (int64, error) {
+	ctx, done := s.start(ctx, "SaveTask")
+
+	result, err := s.next.SaveTask(ctx, newTask)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) GetTasksByUserID(ctx context.Context, userID int64, query task.Query) (*task.Page, error) {
+	ctx, done := s.start(ctx, "GetTasksByUserID")
+
+	result, err := s.next.GetTasksByUserID(ctx, userID, query)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) SearchTasks(ctx context.Context, userID int64, query task.SearchQuery) ([]*task.SearchResult, error) {
+	ctx, done := s.start(ctx, "SearchTasks")
+
+	result, err := s.next.SearchTasks(ctx, userID, query)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) GetTaskByTaskID(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	ctx, done := s.start(ctx, "GetTaskByTaskID")
+
+	result, err := s.next.GetTaskByTaskID(ctx, userID, taskID)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) UpdateTaskContent(ctx context.Context, userID, task_id int64, content string, version int64) (int64, error) {
+	ctx, done := s.start(ctx, "UpdateTaskContent")
+
+	result, err := s.next.UpdateTaskContent(ctx, userID, task_id, content, version)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) UpdateTaskStatus(ctx context.Context, userID, taskID int64, status string, version int64) (*task.Task, error) {
+	ctx, done := s.start(ctx, "UpdateTaskStatus")
+
+	result, err := s.next.UpdateTaskStatus(ctx, userID, taskID, status, version)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) ToggleTaskCompletion(ctx context.Context, userID, taskID int64, version int64) (*task.Task, error) {
+	ctx, done := s.start(ctx, "ToggleTaskCompletion")
+
+	result, err := s.next.ToggleTaskCompletion(ctx, userID, taskID, version)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) UpdateTaskDueAt(ctx context.Context, userID, taskID int64, dueAt *time.Time, version int64) (int64, error) {
+	ctx, done := s.start(ctx, "UpdateTaskDueAt")
+
+	result, err := s.next.UpdateTaskDueAt(ctx, userID, taskID, dueAt, version)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) UpdateTaskPriority(ctx context.Context, userID, taskID int64, priority int, version int64) (int64, error) {
+	ctx, done := s.start(ctx, "UpdateTaskPriority")
+
+	result, err := s.next.UpdateTaskPriority(ctx, userID, taskID, priority, version)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) PatchTask(ctx context.Context, userID, taskID int64, patch task.Patch, version int64) (*task.Task, error) {
+	ctx, done := s.start(ctx, "PatchTask")
+
+	result, err := s.next.PatchTask(ctx, userID, taskID, patch, version)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) DeleteTask(ctx context.Context, userID, task_id int64, version int64) error {
+	ctx, done := s.start(ctx, "DeleteTask")
+
+	err := s.next.DeleteTask(ctx, userID, task_id, version)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) GetTrashedTasks(ctx context.Context, userID int64) ([]*task.Task, error) {
+	ctx, done := s.start(ctx, "GetTrashedTasks")
+
+	result, err := s.next.GetTrashedTasks(ctx, userID)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) RestoreTask(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	ctx, done := s.start(ctx, "RestoreTask")
+
+	result, err := s.next.RestoreTask(ctx, userID, taskID)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) PurgeTask(ctx context.Context, userID, taskID int64) error {
+	ctx, done := s.start(ctx, "PurgeTask")
+
+	err := s.next.PurgeTask(ctx, userID, taskID)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) PurgeTrashedTasks(ctx context.Context, before time.Time) (int64, error) {
+	ctx, done := s.start(ctx, "PurgeTrashedTasks")
+
+	result, err := s.next.PurgeTrashedTasks(ctx, before)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) SaveRefreshToken(ctx context.Context, refreshToken *token.RefreshToken) (int64, error) {
+	ctx, done := s.start(ctx, "SaveRefreshToken")
+
+	result, err := s.next.SaveRefreshToken(ctx, refreshToken)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) GetRefreshTokenByHash(ctx context.Context, hash string) (*token.RefreshToken, error) {
+	ctx, done := s.start(ctx, "GetRefreshTokenByHash")
+
+	result, err := s.next.GetRefreshTokenByHash(ctx, hash)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*token.RefreshToken, error) {
+	ctx, done := s.start(ctx, "RotateRefreshToken")
+
+	result, err := s.next.RotateRefreshToken(ctx, oldHash, newHash, expiresAt)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, done := s.start(ctx, "RevokeRefreshTokenFamily")
+
+	err := s.next.RevokeRefreshTokenFamily(ctx, familyID)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	ctx, done := s.start(ctx, "RevokeAccessToken")
+
+	err := s.next.RevokeAccessToken(ctx, jti, userID, expiresAt)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, done := s.start(ctx, "IsAccessTokenRevoked")
+
+	result, err := s.next.IsAccessTokenRevoked(ctx, jti)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	ctx, done := s.start(ctx, "DeleteExpiredRevokedTokens")
+
+	result, err := s.next.DeleteExpiredRevokedTokens(ctx)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) GetTokensValidAfter(ctx context.Context, userID int64) (time.Time, error) {
+	ctx, done := s.start(ctx, "GetTokensValidAfter")
+
+	result, err := s.next.GetTokensValidAfter(ctx, userID)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) RevokeUserTokens(ctx context.Context, userID int64) error {
+	ctx, done := s.start(ctx, "RevokeUserTokens")
+
+	err := s.next.RevokeUserTokens(ctx, userID)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) SaveIdempotencyKey(ctx context.Context, record *idempotency.Record) error {
+	ctx, done := s.start(ctx, "SaveIdempotencyKey")
+
+	err := s.next.SaveIdempotencyKey(ctx, record)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) GetIdempotencyKey(ctx context.Context, scope, key string) (*idempotency.Record, error) {
+	ctx, done := s.start(ctx, "GetIdempotencyKey")
+
+	result, err := s.next.GetIdempotencyKey(ctx, scope, key)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, body []byte) error {
+	ctx, done := s.start(ctx, "CompleteIdempotencyKey")
+
+	err := s.next.CompleteIdempotencyKey(ctx, scope, key, statusCode, body)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	ctx, done := s.start(ctx, "DeleteIdempotencyKey")
+
+	err := s.next.DeleteIdempotencyKey(ctx, scope, key)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, done := s.start(ctx, "DeleteExpiredIdempotencyKeys")
+
+	result, err := s.next.DeleteExpiredIdempotencyKeys(ctx)
+	done(err)
+	return result, err
+}
+
+func (s *observedStorage) Ping(ctx context.Context) error {
+	ctx, done := s.start(ctx, "Ping")
+
+	err := s.next.Ping(ctx)
+	done(err)
+	return err
+}
+
+func (s *observedStorage) Stats() sql.DBStats {
+	return s.next.Stats()
+}
+
+func (s *observedStorage) Close() error {
+	return s.next.Close()
+}
@@ -0,0 +1,60 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"restapi/internal/models/user"
+)
+
+type callKey struct{}
+
+// recordingObserver remembers the calls it saw and their errors
+type recordingObserver struct {
+	calls []string
+	errs  []error
+}
+
+func (o *recordingObserver) StartCall(ctx context.Context, method string) (context.Context, func(err error)) {
+	o.calls = append(o.calls, method)
+
+	return context.WithValue(ctx, callKey{}, method), func(err error) {
+		o.errs = append(o.errs, err)
+	}
+}
+
+// contextStorage reports whether the observer context reached the storage
+type contextStorage struct {
+	slowStorage
+	sawMethod *string
+}
+
+func (s contextStorage) GetUsers(ctx context.Context) ([]*user.User, error) {
+	*s.sawMethod, _ = ctx.Value(callKey{}).(string)
+	return s.slowStorage.GetUsers(ctx)
+}
+
+func TestObservers(t *testing.T) {
+	var sawMethod string
+	observer := &recordingObserver{}
+	s := WithObservers(WithQueryTimeout(contextStorage{sawMethod: &sawMethod}, 10*time.Millisecond), observer)
+
+	if _, err := s.GetUserByID(context.Background(), 1); err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if _, err := s.GetUsers(context.Background()); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("GetUsers = %v, want DeadlineExceeded", err)
+	}
+
+	if len(observer.calls) != 2 || observer.calls[0] != "GetUserByID" || observer.calls[1] != "GetUsers" {
+		t.Fatalf("observed calls %v, want GetUserByID and GetUsers", observer.calls)
+	}
+	if observer.errs[0] != nil || !errors.Is(observer.errs[1], context.DeadlineExceeded) {
+		t.Fatalf("observed errors %v, want nil and DeadlineExceeded", observer.errs)
+	}
+	if sawMethod != "GetUsers" {
+		t.Fatalf("storage saw context value %q, want the observer context", sawMethod)
+	}
+}
@@ -0,0 +1,767 @@
+// Package storagetest is the conformance suite every storage.Storage implementation must pass
+package storagetest
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"restapi/internal/errorset"
+	"restapi/internal/lib/refreshtoken"
+	"restapi/internal/models/idempotency"
+	"restapi/internal/models/task"
+	"restapi/internal/models/token"
+	"restapi/internal/models/user"
+	"restapi/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// Factory returns an empty storage for one test; cleanup is up to the factory
+type Factory func(t *testing.T) storage.Storage
+
+// Run runs the conformance suite against the storages made by newStorage
+func Run(t *testing.T, newStorage Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s storage.Storage)
+	}{
+		{"Users", testUsers},
+		{"DeleteUserCascades", testDeleteUserCascades},
+		{"Roles", testRoles},
+		{"TaskOwnership", testTaskOwnership},
+		{"TaskFields", testTaskFields},
+		{"TaskVersions", testTaskVersions},
+		{"PatchTask", testPatchTask},
+		{"TaskTrash", testTaskTrash},
+		{"TaskQuery", testTaskQuery},
+		{"SearchTasks", testSearchTasks},
+		{"RefreshTokens", testRefreshTokens},
+		{"AccessTokenRevocation", testAccessTokenRevocation},
+		{"IdempotencyKeys", testIdempotencyKeys},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStorage(t))
+		})
+	}
+}
+
+func testUsers(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+
+	if _, err := s.SaveUser(ctx, "alice", "Password123!"); !errors.Is(err, errorset.ErrDuplicateUser) {
+		t.Fatalf("SaveUser duplicate: got %v, want ErrDuplicateUser", err)
+	}
+
+	got, err := s.GetUserByUsername(ctx, "alice")
+	if err != nil {
+		t.Fatalf("GetUserByUsername: %v", err)
+	}
+	if got.UserID != userID || got.Role != user.RoleUser || got.Disabled() || got.Password == "Password123!" {
+		t.Fatalf("GetUserByUsername: unexpected user %+v", got)
+	}
+
+	if exists, err := s.UsernameExists(ctx, "alice"); err != nil || !exists {
+		t.Fatalf("UsernameExists(alice) = %v, %v", exists, err)
+	}
+	if exists, err := s.UsernameExists(ctx, "bob"); err != nil || exists {
+		t.Fatalf("UsernameExists(bob) = %v, %v", exists, err)
+	}
+
+	if _, err := s.GetUserByID(ctx, userID+1000); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("GetUserByID unknown: got %v, want ErrUserNotFound", err)
+	}
+	if err := s.UpdateUserPassword(ctx, userID+1000, "Password123!"); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("UpdateUserPassword unknown: got %v, want ErrUserNotFound", err)
+	}
+
+	mustSaveUser(t, s, "bob")
+	if err := s.UpdateUsername(ctx, userID, "bob"); !errors.Is(err, errorset.ErrDuplicateUser) {
+		t.Fatalf("UpdateUsername duplicate: got %v, want ErrDuplicateUser", err)
+	}
+	if err := s.UpdateUsername(ctx, userID+1000, "carol"); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("UpdateUsername unknown: got %v, want ErrUserNotFound", err)
+	}
+	if err := s.UpdateUsername(ctx, userID, "alicia"); err != nil {
+		t.Fatalf("UpdateUsername: %v", err)
+	}
+	if got, err := s.GetUserByUsername(ctx, "alicia"); err != nil || got.UserID != userID {
+		t.Fatalf("GetUserByUsername(alicia) = %v, %v", got, err)
+	}
+	if exists, _ := s.UsernameExists(ctx, "alice"); exists {
+		t.Fatal("old username still exists")
+	}
+
+	if err := s.SetUserDisabled(ctx, userID, true); err != nil {
+		t.Fatalf("SetUserDisabled: %v", err)
+	}
+	if got, _ := s.GetUserByID(ctx, userID); !got.Disabled() {
+		t.Fatal("user is not disabled")
+	}
+	if validAfter, err := s.GetTokensValidAfter(ctx, userID); err != nil || validAfter.IsZero() {
+		t.Fatalf("disabling did not revoke tokens: %v, %v", validAfter, err)
+	}
+
+	if err := s.SetUserDisabled(ctx, userID, false); err != nil {
+		t.Fatalf("SetUserDisabled: %v", err)
+	}
+	if got, _ := s.GetUserByID(ctx, userID); got.Disabled() {
+		t.Fatal("user is still disabled")
+	}
+
+	users, err := s.GetUsers(ctx)
+	if err != nil || len(users) != 2 || users[0].UserName != "alicia" || users[1].UserName != "bob" {
+		t.Fatalf("GetUsers = %v, %v", users, err)
+	}
+}
+
+func testDeleteUserCascades(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	taskID := mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "buy milk"})
+	mustSaveRefreshToken(t, s, userID, refreshtoken.Hash("hash-1"), uuid.NewString())
+
+	if err := s.DeleteUser(ctx, userID); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if err := s.DeleteUser(ctx, userID); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("DeleteUser twice: got %v, want ErrUserNotFound", err)
+	}
+
+	if _, err := s.GetTaskByTaskID(ctx, userID, taskID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("task survived its user: %v", err)
+	}
+	if _, err := s.GetRefreshTokenByHash(ctx, refreshtoken.Hash("hash-1")); !errors.Is(err, errorset.ErrRefreshTokenNotFound) {
+		t.Fatalf("refresh token survived its user: %v", err)
+	}
+	if _, err := s.GetTasksByUserID(ctx, userID, task.DefaultQuery()); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("GetTasksByUserID of deleted user: got %v, want ErrUserNotFound", err)
+	}
+	if _, err := s.SaveTask(ctx, &task.Task{UserID: userID, TaskContent: "orphan"}); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("SaveTask for deleted user: got %v, want ErrUserNotFound", err)
+	}
+}
+
+func testRoles(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+
+	if err := s.SaveRole(ctx, "auditor"); err != nil {
+		t.Fatalf("SaveRole: %v", err)
+	}
+	if err := s.SaveRole(ctx, "auditor"); !errors.Is(err, errorset.ErrDuplicateRole) {
+		t.Fatalf("SaveRole duplicate: got %v, want ErrDuplicateRole", err)
+	}
+
+	roles, err := s.GetRoles(ctx)
+	if err != nil || !slices.Equal(roles, []string{user.RoleAdmin, "auditor", user.RoleUser}) {
+		t.Fatalf("GetRoles = %v, %v", roles, err)
+	}
+
+	if err := s.SetUserRole(ctx, userID, "unknown"); !errors.Is(err, errorset.ErrRoleNotFound) {
+		t.Fatalf("SetUserRole unknown role: got %v, want ErrRoleNotFound", err)
+	}
+	if err := s.SetUserRole(ctx, userID+1000, user.RoleAdmin); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("SetUserRole unknown user: got %v, want ErrUserNotFound", err)
+	}
+	if err := s.SetUserRole(ctx, userID, user.RoleAdmin); err != nil {
+		t.Fatalf("SetUserRole: %v", err)
+	}
+	if got, _ := s.GetUserByID(ctx, userID); got.Role != user.RoleAdmin {
+		t.Fatalf("role = %q, want admin", got.Role)
+	}
+}
+
+func testTaskOwnership(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	owner := mustSaveUser(t, s, "alice")
+	stranger := mustSaveUser(t, s, "bob")
+	taskID := mustSaveTask(t, s, &task.Task{UserID: owner, TaskContent: "buy milk"})
+
+	if _, err := s.GetTaskByTaskID(ctx, stranger, taskID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("GetTaskByTaskID by stranger: got %v, want ErrTaskNotFound", err)
+	}
+	if _, err := s.UpdateTaskContent(ctx, stranger, taskID, "hijacked", task.AnyVersion); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("UpdateTaskContent by stranger: got %v, want ErrTaskNotFound", err)
+	}
+	if _, err := s.ToggleTaskCompletion(ctx, stranger, taskID, task.AnyVersion); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("ToggleTaskCompletion by stranger: got %v, want ErrTaskNotFound", err)
+	}
+	if err := s.DeleteTask(ctx, stranger, taskID, task.AnyVersion); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("DeleteTask by stranger: got %v, want ErrTaskNotFound", err)
+	}
+
+	page, err := s.GetTasksByUserID(ctx, stranger, task.DefaultQuery())
+	if err != nil || len(page.Tasks) != 0 {
+		t.Fatalf("stranger sees tasks: %v, %v", page, err)
+	}
+
+	if _, err := s.UpdateTaskContent(ctx, owner, taskID, "buy oat milk", task.AnyVersion); err != nil {
+		t.Fatalf("UpdateTaskContent: %v", err)
+	}
+	if got, _ := s.GetTaskByTaskID(ctx, owner, taskID); got.TaskContent != "buy oat milk" {
+		t.Fatalf("content = %q", got.TaskContent)
+	}
+
+	if err := s.DeleteTask(ctx, owner, taskID, task.AnyVersion); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if err := s.DeleteTask(ctx, owner, taskID, task.AnyVersion); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("DeleteTask twice: got %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testTaskFields(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	taskID := mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "buy milk"})
+
+	got, err := s.GetTaskByTaskID(ctx, userID, taskID)
+	if err != nil {
+		t.Fatalf("GetTaskByTaskID: %v", err)
+	}
+	if got.Status != task.StatusTodo || got.Priority != task.PriorityNone || got.DueAt != nil || got.CompletedAt != nil {
+		t.Fatalf("unexpected defaults %+v", got)
+	}
+
+	toggled, err := s.ToggleTaskCompletion(ctx, userID, taskID, task.AnyVersion)
+	if err != nil || toggled.Status != task.StatusDone || toggled.CompletedAt == nil || !toggled.Completed() {
+		t.Fatalf("ToggleTaskCompletion = %+v, %v", toggled, err)
+	}
+
+	// setting done again keeps the completion of the first write
+	again, err := s.UpdateTaskStatus(ctx, userID, taskID, task.StatusDone, task.AnyVersion)
+	if err != nil || again.Completed() || !again.CompletedAt.Equal(*toggled.CompletedAt) {
+		t.Fatalf("UpdateTaskStatus done again = %+v, %v, want the completion of the toggle kept", again, err)
+	}
+
+	if _, err := s.UpdateTaskStatus(ctx, userID, taskID, task.StatusInProgress, task.AnyVersion); err != nil {
+		t.Fatalf("UpdateTaskStatus: %v", err)
+	}
+	if got, _ := s.GetTaskByTaskID(ctx, userID, taskID); got.Status != task.StatusInProgress || got.CompletedAt != nil {
+		t.Fatalf("completed_at not cleared: %+v", got)
+	}
+
+	dueAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := s.UpdateTaskDueAt(ctx, userID, taskID, &dueAt, task.AnyVersion); err != nil {
+		t.Fatalf("UpdateTaskDueAt: %v", err)
+	}
+	if _, err := s.UpdateTaskPriority(ctx, userID, taskID, task.PriorityHigh, task.AnyVersion); err != nil {
+		t.Fatalf("UpdateTaskPriority: %v", err)
+	}
+
+	got, _ = s.GetTaskByTaskID(ctx, userID, taskID)
+	if got.DueAt == nil || !got.DueAt.Equal(dueAt) || got.Priority != task.PriorityHigh {
+		t.Fatalf("due date or priority not saved: %+v", got)
+	}
+	if got.UpdatedAt.Before(got.CreatedAt) {
+		t.Fatalf("updated_at %v before created_at %v", got.UpdatedAt, got.CreatedAt)
+	}
+
+	if _, err := s.UpdateTaskDueAt(ctx, userID, taskID, nil, task.AnyVersion); err != nil {
+		t.Fatalf("UpdateTaskDueAt nil: %v", err)
+	}
+	if got, _ := s.GetTaskByTaskID(ctx, userID, taskID); got.DueAt != nil {
+		t.Fatalf("due date not cleared: %v", got.DueAt)
+	}
+}
+
+func testTaskVersions(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	taskID := mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "buy milk"})
+
+	if got, _ := s.GetTaskByTaskID(ctx, userID, taskID); got.Version != 1 {
+		t.Fatalf("version of a new task = %d, want 1", got.Version)
+	}
+
+	version, err := s.UpdateTaskContent(ctx, userID, taskID, "buy oat milk", 1)
+	if err != nil || version != 2 {
+		t.Fatalf("UpdateTaskContent at version 1 = %d, %v, want 2", version, err)
+	}
+	if _, err := s.UpdateTaskContent(ctx, userID, taskID, "stale", 1); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("UpdateTaskContent at stale version: got %v, want ErrVersionMismatch", err)
+	}
+	if got, _ := s.GetTaskByTaskID(ctx, userID, taskID); got.TaskContent != "buy oat milk" {
+		t.Fatalf("stale update was applied: content = %q", got.TaskContent)
+	}
+
+	// every write bumps the version and is refused at a stale one
+	if version, err := s.UpdateTaskPriority(ctx, userID, taskID, task.PriorityHigh, 2); err != nil || version != 3 {
+		t.Fatalf("UpdateTaskPriority at version 2 = %d, %v, want 3", version, err)
+	}
+	if toggled, err := s.ToggleTaskCompletion(ctx, userID, taskID, 3); err != nil || toggled.Version != 4 {
+		t.Fatalf("ToggleTaskCompletion at version 3 = %+v, %v, want version 4", toggled, err)
+	}
+	if updated, err := s.UpdateTaskStatus(ctx, userID, taskID, task.StatusInProgress, 4); err != nil || updated.Version != 5 {
+		t.Fatalf("UpdateTaskStatus at version 4 = %+v, %v, want version 5", updated, err)
+	}
+	dueAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	if version, err := s.UpdateTaskDueAt(ctx, userID, taskID, &dueAt, 5); err != nil || version != 6 {
+		t.Fatalf("UpdateTaskDueAt at version 5 = %d, %v, want 6", version, err)
+	}
+
+	if _, err := s.UpdateTaskPriority(ctx, userID, taskID, task.PriorityLow, 5); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("UpdateTaskPriority at stale version: got %v, want ErrVersionMismatch", err)
+	}
+	if _, err := s.ToggleTaskCompletion(ctx, userID, taskID, 5); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("ToggleTaskCompletion at stale version: got %v, want ErrVersionMismatch", err)
+	}
+	if _, err := s.UpdateTaskStatus(ctx, userID, taskID, task.StatusDone, 5); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("UpdateTaskStatus at stale version: got %v, want ErrVersionMismatch", err)
+	}
+	if _, err := s.UpdateTaskDueAt(ctx, userID, taskID, nil, 5); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("UpdateTaskDueAt at stale version: got %v, want ErrVersionMismatch", err)
+	}
+	if got, _ := s.GetTaskByTaskID(ctx, userID, taskID); got.Version != 6 || got.Status != task.StatusInProgress || got.DueAt == nil {
+		t.Fatalf("stale writes were applied: %+v", got)
+	}
+
+	if err := s.DeleteTask(ctx, userID, taskID, 2); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("DeleteTask at stale version: got %v, want ErrVersionMismatch", err)
+	}
+	if err := s.DeleteTask(ctx, userID, taskID, 6); err != nil {
+		t.Fatalf("DeleteTask at version 6: %v", err)
+	}
+	if _, err := s.UpdateTaskContent(ctx, userID, taskID, "gone", 6); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("UpdateTaskContent of deleted task: got %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testPatchTask(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	due := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	taskID := mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "buy milk", Priority: task.PriorityLow, DueAt: &due})
+
+	content, status := "buy oat milk", task.StatusDone
+	patched, err := s.PatchTask(ctx, userID, taskID, task.Patch{TaskContent: &content, Status: &status}, 1)
+	if err != nil {
+		t.Fatalf("PatchTask: %v", err)
+	}
+	if patched.TaskContent != content || patched.Status != status || patched.CompletedAt == nil || patched.Version != 2 {
+		t.Fatalf("patched task = %+v, want new content, done with completedAt at version 2", patched)
+	}
+	// fields left out of the patch keep their value
+	if patched.Priority != task.PriorityLow || patched.DueAt == nil || !patched.DueAt.Equal(due) {
+		t.Fatalf("untouched fields changed: priority %d, dueAt %v", patched.Priority, patched.DueAt)
+	}
+
+	patched, err = s.PatchTask(ctx, userID, taskID, task.Patch{SetDueAt: true}, task.AnyVersion)
+	if err != nil || patched.DueAt != nil || patched.Version != 3 {
+		t.Fatalf("PatchTask clearing dueAt = %+v, %v, want no due date at version 3", patched, err)
+	}
+
+	if _, err := s.PatchTask(ctx, userID, taskID, task.Patch{TaskContent: &content}, 2); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("PatchTask at stale version: got %v, want ErrVersionMismatch", err)
+	}
+
+	// an empty patch writes nothing but still checks the version
+	if unchanged, err := s.PatchTask(ctx, userID, taskID, task.Patch{}, 3); err != nil || unchanged.Version != 3 {
+		t.Fatalf("empty PatchTask = %+v, %v, want version 3", unchanged, err)
+	}
+	if _, err := s.PatchTask(ctx, userID, taskID, task.Patch{}, 2); !errors.Is(err, errorset.ErrVersionMismatch) {
+		t.Fatalf("empty PatchTask at stale version: got %v, want ErrVersionMismatch", err)
+	}
+
+	if _, err := s.PatchTask(ctx, userID+1000, taskID, task.Patch{TaskContent: &content}, task.AnyVersion); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("PatchTask by another user: got %v, want ErrTaskNotFound", err)
+	}
+}
+
+func testTaskTrash(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	stranger := mustSaveUser(t, s, "bob")
+	trashedID := mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "buy milk"})
+	keptID := mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "buy bread"})
+
+	if err := s.DeleteTask(ctx, userID, trashedID, 1); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+
+	// trashed tasks are out of every normal query and write
+	if _, err := s.GetTaskByTaskID(ctx, userID, trashedID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("GetTaskByTaskID of trashed task: got %v, want ErrTaskNotFound", err)
+	}
+	if page, err := s.GetTasksByUserID(ctx, userID, task.DefaultQuery()); err != nil || len(page.Tasks) != 1 || page.Tasks[0].TaskID != keptID {
+		t.Fatalf("GetTasksByUserID = %+v, %v, want only the kept task", page, err)
+	}
+	if results, err := s.SearchTasks(ctx, userID, task.SearchQuery{Terms: []string{"milk"}, Limit: 10}); err != nil || len(results) != 0 {
+		t.Fatalf("SearchTasks finds trashed task: %+v, %v", results, err)
+	}
+	if _, err := s.UpdateTaskContent(ctx, userID, trashedID, "changed", task.AnyVersion); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("UpdateTaskContent of trashed task: got %v, want ErrTaskNotFound", err)
+	}
+	if err := s.DeleteTask(ctx, userID, trashedID, task.AnyVersion); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("DeleteTask of trashed task: got %v, want ErrTaskNotFound", err)
+	}
+
+	trash, err := s.GetTrashedTasks(ctx, userID)
+	if err != nil || len(trash) != 1 || trash[0].TaskID != trashedID || trash[0].DeletedAt == nil || trash[0].Version != 2 {
+		t.Fatalf("GetTrashedTasks = %+v, %v, want the trashed task at version 2", trash, err)
+	}
+	if trash, _ := s.GetTrashedTasks(ctx, stranger); len(trash) != 0 {
+		t.Fatalf("stranger sees trashed tasks: %+v", trash)
+	}
+
+	if _, err := s.RestoreTask(ctx, stranger, trashedID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("RestoreTask by stranger: got %v, want ErrTaskNotFound", err)
+	}
+	if _, err := s.RestoreTask(ctx, userID, keptID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("RestoreTask of task not in the trash: got %v, want ErrTaskNotFound", err)
+	}
+	restored, err := s.RestoreTask(ctx, userID, trashedID)
+	if err != nil || restored.DeletedAt != nil || restored.Version != 3 || restored.TaskContent != "buy milk" {
+		t.Fatalf("RestoreTask = %+v, %v, want the task out of the trash at version 3", restored, err)
+	}
+	if _, err := s.GetTaskByTaskID(ctx, userID, trashedID); err != nil {
+		t.Fatalf("GetTaskByTaskID of restored task: %v", err)
+	}
+
+	if err := s.PurgeTask(ctx, userID, keptID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("PurgeTask of task not in the trash: got %v, want ErrTaskNotFound", err)
+	}
+	if err := s.DeleteTask(ctx, userID, trashedID, task.AnyVersion); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if err := s.PurgeTask(ctx, stranger, trashedID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("PurgeTask by stranger: got %v, want ErrTaskNotFound", err)
+	}
+	if err := s.PurgeTask(ctx, userID, trashedID); err != nil {
+		t.Fatalf("PurgeTask: %v", err)
+	}
+	if _, err := s.RestoreTask(ctx, userID, trashedID); !errors.Is(err, errorset.ErrTaskNotFound) {
+		t.Fatalf("RestoreTask of purged task: got %v, want ErrTaskNotFound", err)
+	}
+
+	// the background purge only takes tasks trashed before the retention
+	if err := s.DeleteTask(ctx, userID, keptID, task.AnyVersion); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if purged, err := s.PurgeTrashedTasks(ctx, time.Now().Add(-time.Hour)); err != nil || purged != 0 {
+		t.Fatalf("PurgeTrashedTasks before the deletion = %d, %v, want 0", purged, err)
+	}
+	if purged, err := s.PurgeTrashedTasks(ctx, time.Now().Add(time.Hour)); err != nil || purged != 1 {
+		t.Fatalf("PurgeTrashedTasks after the deletion = %d, %v, want 1", purged, err)
+	}
+	if trash, _ := s.GetTrashedTasks(ctx, userID); len(trash) != 0 {
+		t.Fatalf("trash after purge: %+v", trash)
+	}
+}
+
+func testTaskQuery(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+
+	dueAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	ids := []int64{
+		mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "low", Priority: task.PriorityLow}),
+		mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "high 100%", Priority: task.PriorityHigh, DueAt: &dueAt}),
+		mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "high done", Priority: task.PriorityHigh, Status: task.StatusDone}),
+		mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "none", Priority: task.PriorityNone}),
+		mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "low later", Priority: task.PriorityLow}),
+	}
+
+	// -priority with task_id as tiebreak, two tasks per page
+	query := task.DefaultQuery()
+	query.Sort = []task.SortKey{{Field: task.SortPriority, Desc: true}}
+	query.Limit = 2
+
+	var got []int64
+	for pages := 0; ; pages++ {
+		if pages > len(ids) {
+			t.Fatal("pagination does not end")
+		}
+
+		page, err := s.GetTasksByUserID(ctx, userID, query)
+		if err != nil {
+			t.Fatalf("GetTasksByUserID: %v", err)
+		}
+		for _, task := range page.Tasks {
+			got = append(got, task.TaskID)
+		}
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		if query.Cursor, err = task.DecodeCursor(page.NextCursor, query.Sort); err != nil {
+			t.Fatalf("DecodeCursor: %v", err)
+		}
+	}
+
+	if want := []int64{ids[1], ids[2], ids[0], ids[4], ids[3]}; !slices.Equal(got, want) {
+		t.Fatalf("paged order = %v, want %v", got, want)
+	}
+
+	filters := []struct {
+		name   string
+		modify func(q *task.Query)
+		want   []int64
+	}{
+		{"status", func(q *task.Query) { q.Statuses = []string{task.StatusDone} }, []int64{ids[2]}},
+		{"priority", func(q *task.Query) { q.Priorities = []int{task.PriorityLow, task.PriorityNone} }, []int64{ids[0], ids[3], ids[4]}},
+		{"due range", func(q *task.Query) {
+			after, before := dueAt.Add(-time.Hour), dueAt.Add(time.Hour)
+			q.DueAfter, q.DueBefore = &after, &before
+		}, []int64{ids[1]}},
+		{"text is literal", func(q *task.Query) { q.Text = "100%" }, []int64{ids[1]}},
+		{"text is case-insensitive", func(q *task.Query) { q.Text = "LOW" }, []int64{ids[0], ids[4]}},
+		{"due date sorts last when missing", func(q *task.Query) {
+			q.Sort = []task.SortKey{{Field: task.SortDueAt}}
+			q.Limit = 1
+		}, []int64{ids[1]}},
+		{"no match", func(q *task.Query) { q.Text = "nothing" }, []int64{}},
+	}
+
+	for _, tt := range filters {
+		query := task.DefaultQuery()
+		tt.modify(&query)
+
+		page, err := s.GetTasksByUserID(ctx, userID, query)
+		if err != nil {
+			t.Fatalf("%s: GetTasksByUserID: %v", tt.name, err)
+		}
+
+		got := []int64{}
+		for _, task := range page.Tasks {
+			got = append(got, task.TaskID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testSearchTasks(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	stranger := mustSaveUser(t, s, "bob")
+
+	match := mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "Buy groceries and milk"})
+	mustSaveTask(t, s, &task.Task{UserID: userID, TaskContent: "Call the plumber"})
+	mustSaveTask(t, s, &task.Task{UserID: stranger, TaskContent: "Buy groceries too"})
+
+	results, err := s.SearchTasks(ctx, userID, task.SearchQuery{Terms: task.SearchTerms("groc MIL"), Limit: task.DefaultSearchLimit})
+	if err != nil {
+		t.Fatalf("SearchTasks: %v", err)
+	}
+	if len(results) != 1 || results[0].Task.TaskID != match {
+		t.Fatalf("SearchTasks = %v, want task %d", results, match)
+	}
+	if want := "Buy " + task.HighlightStart + "groceries" + task.HighlightStop + " and " + task.HighlightStart + "milk" + task.HighlightStop; results[0].Snippet != want {
+		t.Fatalf("snippet = %q, want %q", results[0].Snippet, want)
+	}
+
+	if _, err := s.SearchTasks(ctx, userID+1000, task.SearchQuery{Terms: []string{"buy"}, Limit: 1}); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("SearchTasks unknown user: got %v, want ErrUserNotFound", err)
+	}
+}
+
+func testRefreshTokens(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	familyID := uuid.NewString()
+	mustSaveRefreshToken(t, s, userID, refreshtoken.Hash("hash-1"), familyID)
+
+	if _, err := s.SaveRefreshToken(ctx, &token.RefreshToken{TokenHash: refreshtoken.Hash("hash-x"), UserID: userID + 1000, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("SaveRefreshToken unknown user: got %v, want ErrUserNotFound", err)
+	}
+
+	next, err := s.RotateRefreshToken(ctx, refreshtoken.Hash("hash-1"), refreshtoken.Hash("hash-2"), time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("RotateRefreshToken: %v", err)
+	}
+	if next.UserID != userID || next.FamilyID != familyID || next.TokenHash != refreshtoken.Hash("hash-2") {
+		t.Fatalf("rotated token %+v", next)
+	}
+
+	// presenting the rotated token again revokes the whole family
+	if _, err := s.RotateRefreshToken(ctx, refreshtoken.Hash("hash-1"), refreshtoken.Hash("hash-3"), time.Now().Add(time.Hour)); !errors.Is(err, errorset.ErrRefreshTokenReused) {
+		t.Fatalf("reuse: got %v, want ErrRefreshTokenReused", err)
+	}
+	if got, _ := s.GetRefreshTokenByHash(ctx, refreshtoken.Hash("hash-2")); got.RevokedAt == nil {
+		t.Fatal("family was not revoked on reuse")
+	}
+
+	if _, err := s.RotateRefreshToken(ctx, refreshtoken.Hash("missing"), refreshtoken.Hash("hash-4"), time.Now().Add(time.Hour)); !errors.Is(err, errorset.ErrRefreshTokenNotFound) {
+		t.Fatalf("unknown token: got %v, want ErrRefreshTokenNotFound", err)
+	}
+
+	expiredFamily := uuid.NewString()
+	if _, err := s.SaveRefreshToken(ctx, &token.RefreshToken{TokenHash: refreshtoken.Hash("hash-5"), UserID: userID, FamilyID: expiredFamily, ExpiresAt: time.Now().Add(-time.Minute)}); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+	if _, err := s.RotateRefreshToken(ctx, refreshtoken.Hash("hash-5"), refreshtoken.Hash("hash-6"), time.Now().Add(time.Hour)); !errors.Is(err, errorset.ErrRefreshTokenExpired) {
+		t.Fatalf("expired token: got %v, want ErrRefreshTokenExpired", err)
+	}
+
+	mustSaveRefreshToken(t, s, userID, refreshtoken.Hash("hash-7"), uuid.NewString())
+	if err := s.UpdateUserPassword(ctx, userID, "NewPassword123!"); err != nil {
+		t.Fatalf("UpdateUserPassword: %v", err)
+	}
+	if got, _ := s.GetRefreshTokenByHash(ctx, refreshtoken.Hash("hash-7")); got.RevokedAt == nil {
+		t.Fatal("password change did not revoke refresh tokens")
+	}
+}
+
+func testAccessTokenRevocation(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	userID := mustSaveUser(t, s, "alice")
+	jti := uuid.NewString()
+
+	if validAfter, err := s.GetTokensValidAfter(ctx, userID); err != nil || !validAfter.IsZero() {
+		t.Fatalf("GetTokensValidAfter of new user = %v, %v", validAfter, err)
+	}
+
+	if revoked, err := s.IsAccessTokenRevoked(ctx, jti); err != nil || revoked {
+		t.Fatalf("IsAccessTokenRevoked before revocation = %v, %v", revoked, err)
+	}
+
+	for range 2 {
+		if err := s.RevokeAccessToken(ctx, jti, userID, time.Now().Add(time.Hour)); err != nil {
+			t.Fatalf("RevokeAccessToken: %v", err)
+		}
+	}
+
+	if revoked, err := s.IsAccessTokenRevoked(ctx, jti); err != nil || !revoked {
+		t.Fatalf("IsAccessTokenRevoked after revocation = %v, %v", revoked, err)
+	}
+
+	if err := s.RevokeAccessToken(ctx, uuid.NewString(), userID+1000, time.Now().Add(time.Hour)); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("RevokeAccessToken unknown user: got %v, want ErrUserNotFound", err)
+	}
+
+	// an expired token is rejected anyway, so its entry can go
+	expired := uuid.NewString()
+	if err := s.RevokeAccessToken(ctx, expired, userID, time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("RevokeAccessToken expired: %v", err)
+	}
+	if deleted, err := s.DeleteExpiredRevokedTokens(ctx); err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpiredRevokedTokens = %d, %v, want 1", deleted, err)
+	}
+	if revoked, err := s.IsAccessTokenRevoked(ctx, expired); err != nil || revoked {
+		t.Fatalf("IsAccessTokenRevoked after the purge = %v, %v, want it deleted", revoked, err)
+	}
+	if revoked, err := s.IsAccessTokenRevoked(ctx, jti); err != nil || !revoked {
+		t.Fatalf("IsAccessTokenRevoked of a live token after the purge = %v, %v", revoked, err)
+	}
+
+	if err := s.RevokeUserTokens(ctx, userID); err != nil {
+		t.Fatalf("RevokeUserTokens: %v", err)
+	}
+	if validAfter, err := s.GetTokensValidAfter(ctx, userID); err != nil || validAfter.IsZero() {
+		t.Fatalf("GetTokensValidAfter after revocation = %v, %v", validAfter, err)
+	}
+
+	if err := s.RevokeUserTokens(ctx, userID+1000); !errors.Is(err, errorset.ErrUserNotFound) {
+		t.Fatalf("RevokeUserTokens unknown user: got %v, want ErrUserNotFound", err)
+	}
+}
+
+func testIdempotencyKeys(t *testing.T, s storage.Storage) {
+	ctx := context.Background()
+	record := &idempotency.Record{Scope: "user:1", Key: "key-1", RequestHash: "hash-1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	if _, err := s.GetIdempotencyKey(ctx, "user:1", "key-1"); !errors.Is(err, errorset.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("GetIdempotencyKey before saving: got %v, want ErrIdempotencyKeyNotFound", err)
+	}
+
+	if err := s.SaveIdempotencyKey(ctx, record); err != nil {
+		t.Fatalf("SaveIdempotencyKey: %v", err)
+	}
+	if err := s.SaveIdempotencyKey(ctx, record); !errors.Is(err, errorset.ErrIdempotencyKeyExists) {
+		t.Fatalf("SaveIdempotencyKey twice: got %v, want ErrIdempotencyKeyExists", err)
+	}
+
+	// keys are scoped to their user or service
+	if err := s.SaveIdempotencyKey(ctx, &idempotency.Record{Scope: "user:2", Key: "key-1", RequestHash: "hash-2", ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("SaveIdempotencyKey of another scope: %v", err)
+	}
+
+	got, err := s.GetIdempotencyKey(ctx, "user:1", "key-1")
+	if err != nil || got.RequestHash != "hash-1" || got.Done() {
+		t.Fatalf("GetIdempotencyKey in progress = %+v, %v", got, err)
+	}
+
+	if err := s.CompleteIdempotencyKey(ctx, "user:1", "key-1", 201, []byte(`{"taskId":1}`)); err != nil {
+		t.Fatalf("CompleteIdempotencyKey: %v", err)
+	}
+	got, err = s.GetIdempotencyKey(ctx, "user:1", "key-1")
+	if err != nil || got.StatusCode != 201 || string(got.Body) != `{"taskId":1}` {
+		t.Fatalf("GetIdempotencyKey done = %+v, %v", got, err)
+	}
+
+	if err := s.CompleteIdempotencyKey(ctx, "user:1", "unknown", 201, nil); !errors.Is(err, errorset.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("CompleteIdempotencyKey unknown key: got %v, want ErrIdempotencyKeyNotFound", err)
+	}
+
+	if err := s.DeleteIdempotencyKey(ctx, "user:2", "key-1"); err != nil {
+		t.Fatalf("DeleteIdempotencyKey: %v", err)
+	}
+	if _, err := s.GetIdempotencyKey(ctx, "user:2", "key-1"); !errors.Is(err, errorset.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("GetIdempotencyKey after delete: got %v, want ErrIdempotencyKeyNotFound", err)
+	}
+
+	expired := &idempotency.Record{Scope: "user:1", Key: "key-2", RequestHash: "hash-1", ExpiresAt: time.Now().Add(-time.Minute)}
+	if err := s.SaveIdempotencyKey(ctx, expired); err != nil {
+		t.Fatalf("SaveIdempotencyKey expired: %v", err)
+	}
+	if _, err := s.GetIdempotencyKey(ctx, "user:1", "key-2"); !errors.Is(err, errorset.ErrIdempotencyKeyNotFound) {
+		t.Fatalf("GetIdempotencyKey expired: got %v, want ErrIdempotencyKeyNotFound", err)
+	}
+
+	// an expired key is taken over by the next request
+	expired.RequestHash = "hash-2"
+	expired.ExpiresAt = time.Now().Add(-time.Second)
+	if err := s.SaveIdempotencyKey(ctx, expired); err != nil {
+		t.Fatalf("SaveIdempotencyKey over an expired key: %v", err)
+	}
+
+	if deleted, err := s.DeleteExpiredIdempotencyKeys(ctx); err != nil || deleted != 1 {
+		t.Fatalf("DeleteExpiredIdempotencyKeys = %d, %v, want 1", deleted, err)
+	}
+	if _, err := s.GetIdempotencyKey(ctx, "user:1", "key-1"); err != nil {
+		t.Fatalf("GetIdempotencyKey of a live key after the purge: %v", err)
+	}
+}
+
+func mustSaveUser(t *testing.T, s storage.Storage, username string) int64 {
+	ctx := context.Background()
+	t.Helper()
+
+	userID, err := s.SaveUser(ctx, username, "Password123!")
+	if err != nil {
+		t.Fatalf("SaveUser(%s): %v", username, err)
+	}
+
+	return userID
+}
+
+func mustSaveTask(t *testing.T, s storage.Storage, newTask *task.Task) int64 {
+	ctx := context.Background()
+	t.Helper()
+
+	taskID, err := s.SaveTask(ctx, newTask)
+	if err != nil {
+		t.Fatalf("SaveTask: %v", err)
+	}
+
+	return taskID
+}
+
+func mustSaveRefreshToken(t *testing.T, s storage.Storage, userID int64, hash, familyID string) {
+	ctx := context.Background()
+	t.Helper()
+
+	refreshToken := &token.RefreshToken{TokenHash: hash, UserID: userID, FamilyID: familyID, ExpiresAt: time.Now().Add(time.Hour)}
+	if _, err := s.SaveRefreshToken(ctx, refreshToken); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+}
@@ -0,0 +1,382 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"restapi/internal/models/audit"
+	"restapi/internal/models/idempotency"
+	"restapi/internal/models/task"
+	"restapi/internal/models/token"
+	"restapi/internal/models/user"
+)
+
+// timeoutStorage bounds every call to the wrapped storage by a query timeout
+type timeoutStorage struct {
+	next    Storage
+	timeout time.Duration
+}
+
+// WithQueryTimeout wraps s so that every call is cancelled after timeout,
+// or earlier when the caller's context is done. A non-positive timeout only propagates the caller's context.
+func WithQueryTimeout(s Storage, timeout time.Duration) Storage {
+	return &timeoutStorage{next: s, timeout: timeout}
+}
+
+func (s *timeoutStorage) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, s.timeout)
+}
+
+// contextError makes a failure caused by a done ctx match ctx.Err(),
+// database drivers report an interrupted query with errors of their own
+func contextError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil || errors.Is(err, ctx.Err()) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %w", ctx.Err(), err)
+}
+
+func (s *timeoutStorage) SaveUser(ctx context.Context, username, password string) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.SaveUser(ctx, username, password)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetUserByID(ctx, id)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) GetUserByUsername(ctx context.Context, username string) (*user.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetUserByUsername(ctx, username)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) UsernameExists(ctx context.Context, name string) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.UsernameExists(ctx, name)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) UpdateUsername(ctx context.Context, id int64, username string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.UpdateUsername(ctx, id, username))
+}
+
+func (s *timeoutStorage) UpdateUserPassword(ctx context.Context, id int64, password string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.UpdateUserPassword(ctx, id, password))
+}
+
+func (s *timeoutStorage) DeleteUser(ctx context.Context, id int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.DeleteUser(ctx, id))
+}
+
+func (s *timeoutStorage) GetUsers(ctx context.Context) ([]*user.User, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetUsers(ctx)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) SetUserDisabled(ctx context.Context, id int64, disabled bool) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.SetUserDisabled(ctx, id, disabled))
+}
+
+func (s *timeoutStorage) SetUserRole(ctx context.Context, id int64, role string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.SetUserRole(ctx, id, role))
+}
+
+func (s *timeoutStorage) GetRoles(ctx context.Context) ([]string, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetRoles(ctx)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) SaveRole(ctx context.Context, role string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.SaveRole(ctx, role))
+}
+
+func (s *timeoutStorage) SaveAuditLog(ctx context.Context, entry *audit.Entry) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.SaveAuditLog(ctx, entry)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) SaveTask(ctx context.Context, newTask *task.Task) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.SaveTask(ctx, newTask)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) GetTasksByUserID(ctx context.Context, userID int64, query task.Query) (*task.Page, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetTasksByUserID(ctx, userID, query)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) SearchTasks(ctx context.Context, userID int64, query task.SearchQuery) ([]*task.SearchResult, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.SearchTasks(ctx, userID, query)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) GetTaskByTaskID(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetTaskByTaskID(ctx, userID, taskID)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) UpdateTaskContent(ctx context.Context, userID, task_id int64, content string, version int64) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.UpdateTaskContent(ctx, userID, task_id, content, version)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) UpdateTaskStatus(ctx context.Context, userID, taskID int64, status string, version int64) (*task.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.UpdateTaskStatus(ctx, userID, taskID, status, version)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) ToggleTaskCompletion(ctx context.Context, userID, taskID int64, version int64) (*task.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.ToggleTaskCompletion(ctx, userID, taskID, version)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) UpdateTaskDueAt(ctx context.Context, userID, taskID int64, dueAt *time.Time, version int64) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.UpdateTaskDueAt(ctx, userID, taskID, dueAt, version)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) UpdateTaskPriority(ctx context.Context, userID, taskID int64, priority int, version int64) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.UpdateTaskPriority(ctx, userID, taskID, priority, version)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) PatchTask(ctx context.Context, userID, taskID int64, patch task.Patch, version int64) (*task.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.PatchTask(ctx, userID, taskID, patch, version)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) DeleteTask(ctx context.Context, userID, task_id int64, version int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.DeleteTask(ctx, userID, task_id, version))
+}
+
+func (s *timeoutStorage) GetTrashedTasks(ctx context.Context, userID int64) ([]*task.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetTrashedTasks(ctx, userID)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) RestoreTask(ctx context.Context, userID, taskID int64) (*task.Task, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.RestoreTask(ctx, userID, taskID)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) PurgeTask(ctx context.Context, userID, taskID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.PurgeTask(ctx, userID, taskID))
+}
+
+func (s *timeoutStorage) PurgeTrashedTasks(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.PurgeTrashedTasks(ctx, before)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) SaveRefreshToken(ctx context.Context, refreshToken *token.RefreshToken) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.SaveRefreshToken(ctx, refreshToken)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) GetRefreshTokenByHash(ctx context.Context, hash string) (*token.RefreshToken, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetRefreshTokenByHash(ctx, hash)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time) (*token.RefreshToken, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.RotateRefreshToken(ctx, oldHash, newHash, expiresAt)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.RevokeRefreshTokenFamily(ctx, familyID))
+}
+
+func (s *timeoutStorage) RevokeAccessToken(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.RevokeAccessToken(ctx, jti, userID, expiresAt))
+}
+
+func (s *timeoutStorage) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.IsAccessTokenRevoked(ctx, jti)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) DeleteExpiredRevokedTokens(ctx context.Context) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.DeleteExpiredRevokedTokens(ctx)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) GetTokensValidAfter(ctx context.Context, userID int64) (time.Time, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetTokensValidAfter(ctx, userID)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) RevokeUserTokens(ctx context.Context, userID int64) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.RevokeUserTokens(ctx, userID))
+}
+
+func (s *timeoutStorage) SaveIdempotencyKey(ctx context.Context, record *idempotency.Record) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.SaveIdempotencyKey(ctx, record))
+}
+
+func (s *timeoutStorage) GetIdempotencyKey(ctx context.Context, scope, key string) (*idempotency.Record, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.GetIdempotencyKey(ctx, scope, key)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) CompleteIdempotencyKey(ctx context.Context, scope, key string, statusCode int, body []byte) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.CompleteIdempotencyKey(ctx, scope, key, statusCode, body))
+}
+
+func (s *timeoutStorage) DeleteIdempotencyKey(ctx context.Context, scope, key string) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.DeleteIdempotencyKey(ctx, scope, key))
+}
+
+func (s *timeoutStorage) DeleteExpiredIdempotencyKeys(ctx context.Context) (int64, error) {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	result, err := s.next.DeleteExpiredIdempotencyKeys(ctx)
+	return result, contextError(ctx, err)
+}
+
+func (s *timeoutStorage) Ping(ctx context.Context) error {
+	ctx, cancel := s.withTimeout(ctx)
+	defer cancel()
+
+	return contextError(ctx, s.next.Ping(ctx))
+}
+
+func (s *timeoutStorage) Stats() sql.DBStats {
+	return s.next.Stats()
+}
+
+func (s *timeoutStorage) Close() error {
+	return s.next.Close()
+}
@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"restapi/internal/models/user"
+)
+
+var errInterrupted = errors.New("driver: query interrupted")
+
+// slowStorage blocks until the context is done and fails like a driver would;
+// other methods panic via the nil interface
+type slowStorage struct {
+	Storage
+}
+
+func (s slowStorage) GetUsers(ctx context.Context) ([]*user.User, error) {
+	<-ctx.Done()
+	return nil, errInterrupted
+}
+
+func (s slowStorage) GetUserByID(ctx context.Context, id int64) (*user.User, error) {
+	return &user.User{UserID: id}, nil
+}
+
+func TestQueryTimeout(t *testing.T) {
+	s := WithQueryTimeout(slowStorage{}, 10*time.Millisecond)
+
+	_, err := s.GetUsers(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errInterrupted) {
+		t.Fatalf("GetUsers = %v, want DeadlineExceeded wrapping the driver error", err)
+	}
+
+	if got, err := s.GetUserByID(context.Background(), 1); err != nil || got.UserID != 1 {
+		t.Fatalf("GetUserByID = %v, %v, want user 1", got, err)
+	}
+}
+
+func TestQueryTimeoutFollowsCaller(t *testing.T) {
+	s := WithQueryTimeout(slowStorage{}, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := s.GetUsers(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetUsers with a cancelled context = %v, want Canceled", err)
+	}
+}
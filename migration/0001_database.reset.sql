@@ -1,2 +0,0 @@
-TRUNCATE TABLE tasks RESTART IDENTITY CASCADE;
-TRUNCATE TABLE users RESTART IDENTITY CASCADE;
\ No newline at end of file
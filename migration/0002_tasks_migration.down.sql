@@ -1,2 +1 @@
 DROP TABLE IF EXISTS tasks CASCADE;
-DROP TABLE IF EXISTS users CASCADE;
\ No newline at end of file
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS revoked_tokens CASCADE;
+
+ALTER TABLE users DROP COLUMN IF EXISTS tokens_valid_after;
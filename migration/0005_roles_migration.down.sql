@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS audit_logs CASCADE;
+
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
+
+DROP TABLE IF EXISTS roles CASCADE;
@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS roles (
+    role_name VARCHAR(32) PRIMARY KEY,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO roles (role_name) VALUES ('user'), ('admin') ON CONFLICT (role_name) DO NOTHING;
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(32) NOT NULL DEFAULT 'user' REFERENCES roles(role_name);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    audit_log_id SERIAL PRIMARY KEY,
+    actor_id INTEGER NOT NULL, -- no foreign keys, entries outlive deleted users and tasks
+    action VARCHAR(64) NOT NULL,
+    target_user_id INTEGER,
+    target_task_id INTEGER,
+    details TEXT,
+    created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_actor_id_idx ON audit_logs (actor_id);
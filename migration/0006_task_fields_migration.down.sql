@@ -0,0 +1,6 @@
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS completed_at,
+    DROP COLUMN IF EXISTS due_at,
+    DROP COLUMN IF EXISTS priority,
+    DROP COLUMN IF EXISTS updated_at;
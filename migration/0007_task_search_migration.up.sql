@@ -0,0 +1,5 @@
+-- 'simple' keeps words as written, so prefix queries behave the same in every language
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', task_content)) STORED;
+
+CREATE INDEX IF NOT EXISTS tasks_search_vector_idx ON tasks USING GIN (search_vector);
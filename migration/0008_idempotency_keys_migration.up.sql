@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    scope TEXT NOT NULL, -- user or service the key belongs to, such as user:42
+    idempotency_key TEXT NOT NULL,
+    request_hash TEXT NOT NULL, -- SHA-256 of the method, path and body of the first request
+    status_code INTEGER, -- NULL while the first request is in progress
+    response_body BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (scope, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,2 @@
+ALTER TABLE tasks
+    ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1; -- bumped by every write, served as the ETag of the task
@@ -0,0 +1,7 @@
+-- without the column trashed tasks would come back, so they are purged first
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS tasks_deleted_at_idx;
+
+ALTER TABLE tasks
+    DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,5 @@
+TRUNCATE TABLE audit_logs RESTART IDENTITY CASCADE;
+TRUNCATE TABLE revoked_tokens RESTART IDENTITY CASCADE;
+TRUNCATE TABLE refresh_tokens RESTART IDENTITY CASCADE;
+TRUNCATE TABLE tasks RESTART IDENTITY CASCADE;
+TRUNCATE TABLE users RESTART IDENTITY CASCADE;
\ No newline at end of file
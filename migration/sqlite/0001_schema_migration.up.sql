@@ -0,0 +1,64 @@
+-- SQLite schema, kept equivalent to the PostgreSQL migrations in ../
+-- timestamps are TEXT in the fixed width UTC format 2006-01-02T15:04:05.000000000Z, so they sort chronologically
+
+CREATE TABLE IF NOT EXISTS roles (
+    role_name TEXT PRIMARY KEY,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z')
+);
+
+INSERT INTO roles (role_name) VALUES ('user'), ('admin') ON CONFLICT (role_name) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS users (
+    user_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    username TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL, -- Hashed password
+    role TEXT NOT NULL DEFAULT 'user' REFERENCES roles(role_name),
+    disabled_at TEXT,
+    tokens_valid_after TEXT, -- access tokens issued before this are rejected
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z')
+);
+
+CREATE TABLE IF NOT EXISTS tasks (
+    task_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    task_content TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'todo' CHECK (status IN ('todo', 'in_progress', 'done')),
+    completed_at TEXT, -- set while status is done
+    due_at TEXT,
+    priority INTEGER NOT NULL DEFAULT 0 CHECK (priority BETWEEN 0 AND 3),
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z'),
+    updated_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z')
+);
+
+CREATE INDEX IF NOT EXISTS tasks_user_id_idx ON tasks (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    token_hash TEXT NOT NULL UNIQUE, -- SHA-256 of the raw token
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    family_id TEXT NOT NULL,
+    expires_at TEXT NOT NULL,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z'),
+    revoked_at TEXT
+);
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(user_id) ON DELETE CASCADE,
+    expires_at TEXT NOT NULL, -- row can be removed once the token has expired anyway
+    revoked_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z')
+);
+
+CREATE TABLE IF NOT EXISTS audit_logs (
+    audit_log_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_id INTEGER NOT NULL, -- no foreign keys, entries outlive deleted users and tasks
+    action TEXT NOT NULL,
+    target_user_id INTEGER,
+    target_task_id INTEGER,
+    details TEXT,
+    created_at TEXT NOT NULL DEFAULT (strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z')
+);
+
+CREATE INDEX IF NOT EXISTS audit_logs_actor_id_idx ON audit_logs (actor_id);
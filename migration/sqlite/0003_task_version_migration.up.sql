@@ -0,0 +1 @@
+ALTER TABLE tasks ADD COLUMN version INTEGER NOT NULL DEFAULT 1; -- bumped by every write, served as the ETag of the task
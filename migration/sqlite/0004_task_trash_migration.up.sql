@@ -0,0 +1,3 @@
+ALTER TABLE tasks ADD COLUMN deleted_at TEXT; -- set while the task is in the trash
+
+CREATE INDEX IF NOT EXISTS tasks_deleted_at_idx ON tasks (deleted_at) WHERE deleted_at IS NOT NULL;